@@ -0,0 +1,333 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const AlertEvaluationInterval = time.Second * 10
+
+// Alerts beyond this are dropped oldest first while disconnected
+const AlertMaxPending = 1000
+
+const (
+	AlertStateFiring   string = "FIRING"
+	AlertStateResolved string = "RESOLVED"
+)
+
+const (
+	AlertMetricCPU                = "cpu"
+	AlertMetricMemory             = "memory"
+	AlertMetricSwap               = "swap"
+	AlertMetricLoad               = "load1"
+	AlertMetricDiskRead           = "disk.read"
+	AlertMetricDiskWrite          = "disk.write"
+	AlertMetricZFSUsed            = "zfs.used"
+	AlertMetricContainerCPU       = "container.cpu"
+	AlertMetricContainerMemory    = "container.memory"
+	AlertMetricContainerUnhealthy = "container.unhealthy"
+	AlertMetricSensorTemperature  = "sensor.temperature"
+	AlertMetricProbeFailed        = "probe.failed"
+	AlertMetricProbeLatency       = "probe.latency"
+	AlertMetricProbeTLSExpiry     = "probe.tlsExpiry"
+)
+
+type DaemonAlertManager struct {
+	Mutex   *sync.Mutex
+	States  map[string]*AlertState
+	Pending []WebsocketAlertMessage
+}
+
+type AlertState struct {
+	Since  time.Time
+	Firing bool
+}
+
+// Target names what the value belongs to (a container, pool, sensor...), it's empty for host-wide metrics.
+type AlertValue struct {
+	Metric string
+	Target string
+	Value  float64
+}
+
+func InitAlertManager(handler *Handler) {
+	handler.AlertManager = DaemonAlertManager{
+		Mutex:   &sync.Mutex{},
+		States:  make(map[string]*AlertState),
+		Pending: []WebsocketAlertMessage{},
+	}
+}
+
+func RunAlertEvaluator(handler *Handler) {
+	if len(handler.Config.Alerts) == 0 {
+		return
+	}
+	ticker := time.NewTicker(AlertEvaluationInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		stats := GetLatestStats(handler, AlertEvaluationInterval)
+		values, failed := GetAlertValues(handler, stats)
+		EvaluateAlerts(handler, values, failed)
+	}
+}
+
+// Metrics that failed to be collected are returned separately, so their alerts don't resolve.
+func GetAlertValues(handler *Handler, stats WebsocketRequestStatsReplyMessage) ([]AlertValue, map[string]bool) {
+	failed := make(map[string]bool)
+	cpu := stats.CPU
+	values := []AlertValue{
+		{AlertMetricCPU, "", MetricsFloat32(cpu.User + cpu.System + cpu.Nice + cpu.Irq + cpu.Softirq + cpu.Steal)},
+		{AlertMetricMemory, "", MetricsFloat32(stats.Memory.Used)},
+		{AlertMetricSwap, "", MetricsFloat32(stats.Memory.SwapUsed)},
+		{AlertMetricLoad, "", MetricsFloat32(cpu.Load.Load1)},
+	}
+	for _, disk := range stats.Disks {
+		values = append(values,
+			AlertValue{AlertMetricDiskRead, disk.Parent, float64(disk.Read)},
+			AlertValue{AlertMetricDiskWrite, disk.Parent, float64(disk.Write)},
+		)
+	}
+	for _, sensor := range stats.Sensors {
+		if sensor.Type == SensorTypeTemperature {
+			values = append(values, AlertValue{AlertMetricSensorTemperature, sensor.Chip + "/" + sensor.Label, sensor.Value})
+		}
+	}
+
+	names := make(map[string]string)
+	containers, _ := GetCachedContainers(handler)
+	for _, container := range containers {
+		names[container.ID] = container.Name
+	}
+	for _, usage := range stats.Containers {
+		if name, ok := names[usage.Parent]; ok {
+			values = append(values,
+				AlertValue{AlertMetricContainerCPU, name, MetricsFloat32(usage.CPU)},
+				AlertValue{AlertMetricContainerMemory, name, float64(usage.Memory)},
+			)
+		}
+	}
+
+	// Probe TLS expiry is in days left, so it can be compared like the other thresholds
+	for _, result := range GetProbeResults(handler) {
+		failed := 0.0
+		if !result.Success {
+			failed = 1
+		}
+		values = append(values,
+			AlertValue{AlertMetricProbeFailed, result.ID, failed},
+			AlertValue{AlertMetricProbeLatency, result.ID, result.Latency},
+		)
+		if result.TLSExpiry != nil {
+			values = append(values, AlertValue{AlertMetricProbeTLSExpiry, result.ID, time.Until(time.Unix(*result.TLSExpiry, 0)).Hours() / 24})
+		}
+	}
+
+	// Only query what some rule actually needs, these call out to other tools
+	if IsAlertMetricUsed(handler, AlertMetricZFSUsed) {
+		// A failing zpool also comes back as no pools, so that can't tell the pools went away
+		pools := GetZFSPools(GetDisks())
+		if len(pools) == 0 {
+			failed[AlertMetricZFSUsed] = true
+		}
+		for _, pool := range pools {
+			values = append(values, AlertValue{AlertMetricZFSUsed, pool.Name, (float64(pool.Used) / float64(pool.Size+1)) * 100})
+		}
+	}
+	if IsAlertMetricUsed(handler, AlertMetricContainerUnhealthy) {
+		healthValues, err := GetContainerHealthAlertValues(handler)
+		if err != nil {
+			failed[AlertMetricContainerUnhealthy] = true
+		}
+		values = append(values, healthValues...)
+	}
+
+	return values, failed
+}
+
+func IsAlertMetricUsed(handler *Handler, metric string) bool {
+	for _, rule := range handler.Config.Alerts {
+		if rule.Metric == metric {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetContainerHealthAlertValues(handler *Handler) ([]AlertValue, error) {
+	values := []AlertValue{}
+	unhealthyStdout, err := exec.Command("docker", "ps", "-q", "--no-trunc", "--filter", "health=unhealthy").Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get container health! (%s)", err.Error())
+		return values, err
+	}
+	unhealthy := strings.Fields(string(unhealthyStdout))
+	containers, _ := GetCachedContainers(handler)
+	for _, container := range containers {
+		if container.Status != "running" {
+			continue
+		}
+		value := AlertValue{AlertMetricContainerUnhealthy, container.Name, 0}
+		for _, rawID := range unhealthy {
+			if container.RawID != "" && strings.HasPrefix(rawID, container.RawID) {
+				value.Value = 1
+			}
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func CompareAlertValue(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+func EvaluateAlerts(handler *Handler, values []AlertValue, failed map[string]bool) {
+	now := time.Now()
+	seen := make(map[string]bool)
+	messages := []WebsocketAlertMessage{}
+
+	handler.AlertManager.Mutex.Lock()
+	for _, rule := range handler.Config.Alerts {
+		for _, value := range values {
+			if value.Metric != rule.Metric {
+				continue
+			}
+			if rule.Target != "" {
+				if matched, _ := filepath.Match(rule.Target, value.Target); !matched {
+					continue
+				}
+			}
+			key := rule.ID + "\x00" + value.Target
+			seen[key] = true
+			state, ok := handler.AlertManager.States[key]
+			if !ok {
+				state = &AlertState{}
+				handler.AlertManager.States[key] = state
+			}
+
+			if !CompareAlertValue(value.Value, rule.Operator, rule.Threshold) {
+				state.Since = time.Time{}
+				if state.Firing {
+					state.Firing = false
+					messages = append(messages, NewAlertMessage(rule, value, AlertStateResolved, now))
+				}
+				continue
+			}
+			if state.Since.IsZero() {
+				state.Since = now
+			}
+			if !state.Firing && now.Sub(state.Since) >= time.Duration(rule.Duration)*time.Second {
+				state.Firing = true
+				messages = append(messages, NewAlertMessage(rule, value, AlertStateFiring, now))
+			}
+		}
+	}
+
+	// Targets that went away (a removed container, a disappeared sensor) can't stay firing,
+	// unless the metric just failed to be collected this time
+	for key, state := range handler.AlertManager.States {
+		if seen[key] {
+			continue
+		}
+		ruleID, target, _ := strings.Cut(key, "\x00")
+		var rule *ConfigAlertRule
+		for i := range handler.Config.Alerts {
+			if handler.Config.Alerts[i].ID == ruleID {
+				rule = &handler.Config.Alerts[i]
+			}
+		}
+		if rule != nil && failed[rule.Metric] {
+			continue
+		}
+		if rule != nil && state.Firing {
+			messages = append(messages, NewAlertMessage(*rule, AlertValue{rule.Metric, target, 0}, AlertStateResolved, now))
+		}
+		delete(handler.AlertManager.States, key)
+	}
+	handler.AlertManager.Mutex.Unlock()
+
+	for _, message := range messages {
+		SendAlert(handler, message)
+	}
+}
+
+func NewAlertMessage(rule ConfigAlertRule, value AlertValue, state string, timestamp time.Time) WebsocketAlertMessage {
+	return WebsocketAlertMessage{
+		Type:      WebsocketMessageTypeAlert,
+		Rule:      rule.ID,
+		Metric:    rule.Metric,
+		Target:    value.Target,
+		Value:     value.Value,
+		Operator:  rule.Operator,
+		Threshold: rule.Threshold,
+		Severity:  rule.Severity,
+		State:     state,
+		Timestamp: timestamp.UnixMilli(),
+	}
+}
+
+func SendAlert(handler *Handler, message WebsocketAlertMessage) {
+	if message.State == AlertStateFiring {
+		SleepyWarnLn("Alert firing! (rule: %s, target: %s, value: %v)", message.Rule, message.Target, message.Value)
+	} else {
+		SleepyLogLn("Alert resolved! (rule: %s, target: %s)", message.Rule, message.Target)
+	}
+	NotifyAlert(handler, message)
+	if GetSession(handler) != nil {
+		err := SendWebsocketMessage(handler, message)
+		if err == nil {
+			return
+		}
+		SleepyWarnLn("Failed to send alert, queueing it! (%s)", err.Error())
+	}
+
+	handler.AlertManager.Mutex.Lock()
+	defer handler.AlertManager.Mutex.Unlock()
+	handler.AlertManager.Pending = append(handler.AlertManager.Pending, message)
+	if len(handler.AlertManager.Pending) > AlertMaxPending {
+		handler.AlertManager.Pending = handler.AlertManager.Pending[len(handler.AlertManager.Pending)-AlertMaxPending:]
+	}
+}
+
+// Sends alerts queued while disconnected, in the order they happened.
+func SendPendingAlerts(handler *Handler) {
+	handler.AlertManager.Mutex.Lock()
+	pending := handler.AlertManager.Pending
+	handler.AlertManager.Pending = []WebsocketAlertMessage{}
+	handler.AlertManager.Mutex.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	for i, message := range pending {
+		err := SendWebsocketMessage(handler, message)
+		if err != nil {
+			SleepyWarnLn("Failed to send queued alerts! (%s)", err.Error())
+			handler.AlertManager.Mutex.Lock()
+			handler.AlertManager.Pending = append(pending[i:], handler.AlertManager.Pending...)
+			handler.AlertManager.Mutex.Unlock()
+			return
+		}
+	}
+	SleepyLogLn("Sent %d queued alerts!", len(pending))
+}
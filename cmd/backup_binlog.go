@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Written by mysqldump --master-data=2, older versions use MASTER_ and newer ones SOURCE_
+var BinlogCoordinatesRegex = regexp.MustCompile(`(?:MASTER|SOURCE)_LOG_FILE='([^']+)',\s*(?:MASTER|SOURCE)_LOG_POS=(\d+)`)
+
+type BinlogServer struct {
+	Key         string
+	Credentials ConfigCredentialsDatabase
+	Databases   []string
+}
+
+type BinlogCoordinates struct {
+	File     string
+	Position uint64
+}
+
+// Counts the events in mysqlbinlog output that change data. The format description at the start
+// of every binlog and the transaction bookkeeping around events don't count.
+type BinlogEventCounter struct {
+	Events    int
+	line      []byte
+	statement string
+	inBinlog  bool
+	afterFDE  bool
+}
+
+func (counter *BinlogEventCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' {
+			counter.line = append(counter.line, b)
+			continue
+		}
+		counter.CountLine(string(counter.line))
+		counter.line = counter.line[:0]
+	}
+
+	return len(p), nil
+}
+
+func (counter *BinlogEventCounter) CountLine(line string) {
+	switch {
+	case counter.inBinlog:
+		counter.inBinlog = !strings.HasPrefix(line, "'")
+	case strings.HasPrefix(line, "#"):
+		if strings.Contains(line, "Start: binlog") {
+			counter.afterFDE = true
+		}
+	case strings.HasPrefix(line, "BINLOG '"):
+		counter.inBinlog = true
+		if !counter.afterFDE {
+			counter.Events++
+		}
+		counter.afterFDE = false
+	case counter.statement == "" && (line == "" || strings.HasPrefix(line, "/*!") || strings.HasPrefix(line, "DELIMITER")):
+	default:
+		if counter.statement == "" {
+			counter.statement = line
+		}
+		if !strings.HasSuffix(line, "/*!*/;") {
+			return
+		}
+		statement := strings.ToUpper(counter.statement)
+		counter.statement = ""
+		for _, prefix := range []string{"SET ", "USE ", "BEGIN", "COMMIT", "ROLLBACK", "XA "} {
+			if strings.HasPrefix(statement, prefix) {
+				return
+			}
+		}
+		counter.Events++
+	}
+}
+
+func GetMySQLBinlog() string {
+	return GetMySQLToolSystem(runtime.GOOS, "mysqlbinlog")
+}
+
+func IsBinlogArchived(handler *Handler, database string) bool {
+	for _, id := range handler.Config.Backup.Binlog.Databases {
+		if id == database {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Binary logs are per server, so databases sharing a server share one archiver.
+func GetBinlogServers(handler *Handler) []BinlogServer {
+	servers := []BinlogServer{}
+	for _, database := range handler.Config.Backup.Binlog.Databases {
+		credentials, localDatabase, ok := GetDatabaseCredentials(handler, database)
+		if !ok {
+			SleepyWarnLn("Failed to find database for binlog archiving! (id: %s)", database)
+			continue
+		}
+		key := GetBinlogServerKey(credentials)
+		index := -1
+		for i, server := range servers {
+			if server.Key == key {
+				index = i
+			}
+		}
+		if index == -1 {
+			servers = append(servers, BinlogServer{
+				Key:         key,
+				Credentials: credentials,
+				Databases:   []string{},
+			})
+			index = len(servers) - 1
+		}
+		servers[index].Databases = append(servers[index].Databases, localDatabase.Name)
+	}
+
+	return servers
+}
+
+func GetBinlogServerKey(credentials ConfigCredentialsDatabase) string {
+	return VolumeBackupNameRegex.ReplaceAllString(fmt.Sprintf("%s_%s", credentials.Host, credentials.Port), "_")
+}
+
+func GetBinlogDirectory(handler *Handler, key string) string {
+	return filepath.Join(GetBackupDirectory(handler), "binlog", key)
+}
+
+func RunBinlogArchivers(handler *Handler) {
+	servers := GetBinlogServers(handler)
+	if len(servers) == 0 {
+		return
+	}
+	executable := GetMySQLBinlog()
+	if executable == "" {
+		SleepyWarnLn("Failed to start binlog archiving! (%s)", "could not find 'mysqlbinlog'")
+		return
+	}
+
+	for _, server := range servers {
+		go RunBinlogArchiver(handler, executable, server)
+		go RunBinlogShipper(handler, server)
+	}
+}
+
+func RunBinlogArchiver(handler *Handler, executable string, server BinlogServer) {
+	directory := GetBinlogDirectory(handler, server.Key)
+	os.MkdirAll(directory, 0755)
+	for {
+		start, err := GetBinlogStartFile(handler, server)
+		if err != nil {
+			SleepyWarnLn("Failed to get first binlog! (server: %s, %s)", server.Key, err.Error())
+			time.Sleep(time.Second * 30)
+			continue
+		}
+
+		// Keeps streaming new events until the connection drops, the current file is rewritten on restart
+		credentials := server.Credentials
+		cmd := exec.Command(executable, "--read-from-remote-server", "--raw", "--stop-never",
+			"-h", credentials.Host, "-P", credentials.Port, "-u", credentials.Username, "-p"+credentials.Password,
+			"--result-file="+directory+string(os.PathSeparator), start)
+		err = cmd.Start()
+		if err != nil {
+			SleepyWarnLn("Failed to start binlog archiver! (server: %s, %s)", server.Key, err.Error())
+			time.Sleep(time.Second * 30)
+			continue
+		}
+		SleepyLogLn("Started binlog archiver! (server: %s, from: %s)", server.Key, start)
+		handler.BackupManager.Mutex.Lock()
+		handler.BackupManager.Binlogs[server.Key] = cmd
+		handler.BackupManager.Mutex.Unlock()
+
+		err = cmd.Wait()
+		handler.BackupManager.Mutex.Lock()
+		delete(handler.BackupManager.Binlogs, server.Key)
+		handler.BackupManager.Mutex.Unlock()
+		if err != nil {
+			SleepyWarnLn("Binlog archiver exited! (server: %s, %s)", server.Key, err.Error())
+		}
+		time.Sleep(time.Second * 30)
+	}
+}
+
+func StopBinlogArchivers(handler *Handler) {
+	if handler.BackupManager.Mutex == nil {
+		return
+	}
+	handler.BackupManager.Mutex.Lock()
+	defer handler.BackupManager.Mutex.Unlock()
+	for _, cmd := range handler.BackupManager.Binlogs {
+		cmd.Process.Kill()
+	}
+}
+
+func GetLocalBinlogs(handler *Handler, key string) []string {
+	entries, err := os.ReadDir(GetBinlogDirectory(handler, key))
+	if err != nil {
+		return []string{}
+	}
+
+	// Binlog names end with a zero-padded sequence number, so sorting by name sorts by age
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func GetBinlogStartFile(handler *Handler, server BinlogServer) (string, error) {
+	local := GetLocalBinlogs(handler, server.Key)
+	if len(local) > 0 {
+		return local[len(local)-1], nil
+	}
+
+	client := GetMySQLClient()
+	if client == "" {
+		return "", errors.New("could not find 'mysql'")
+	}
+	credentials := server.Credentials
+	target := MySQLTarget{
+		Executable: client,
+		Args:       []string{"-h", credentials.Host, "-P", credentials.Port, "-u", credentials.Username, "-p" + credentials.Password},
+	}
+	rows, err := target.Query("", "SHOW BINARY LOGS")
+	if err != nil {
+		return "", err
+	}
+	if len(rows) < 1 {
+		return "", errors.New("binary logging is disabled")
+	}
+
+	return rows[0][0], nil
+}
+
+func RunBinlogShipper(handler *Handler, server BinlogServer) {
+	interval := handler.Config.Backup.Binlog.Interval
+	if interval == 0 {
+		interval = 300
+	}
+	statePath := filepath.Join(GetBinlogDirectory(handler, server.Key), "shipped.json")
+	for {
+		time.Sleep(time.Second * time.Duration(interval))
+
+		shipped := []string{}
+		raw, err := os.ReadFile(statePath)
+		if err == nil {
+			json.Unmarshal(raw, &shipped)
+		}
+		isShipped := make(map[string]bool)
+		for _, name := range shipped {
+			isShipped[name] = true
+		}
+
+		// The newest file is still being written to, so only ship the finished ones
+		local := GetLocalBinlogs(handler, server.Key)
+		for i, name := range local {
+			if i == len(local)-1 || isShipped[name] {
+				continue
+			}
+			uploadFileData := UploadFileBinlogData{
+				Type:      UploadFileDataBinlog,
+				Server:    server.Key,
+				Databases: server.Databases,
+				Name:      name,
+			}
+			err := StoreBackup(handler, handler.Config.Backup.Binlog.Destination, filepath.Join(GetBinlogDirectory(handler, server.Key), name), uploadFileData)
+			if err != nil {
+				SleepyWarnLn("Failed to ship binlog! (name: %s, %s)", name, err.Error())
+				break
+			}
+			shipped = append(shipped, name)
+			raw, _ = json.Marshal(shipped)
+			os.WriteFile(statePath, raw, 0644)
+		}
+
+		pruned := PruneBinlogs(handler, server, shipped)
+		if len(pruned) != len(shipped) {
+			raw, _ = json.Marshal(pruned)
+			os.WriteFile(statePath, raw, 0644)
+		}
+	}
+}
+
+// Binlogs are only useful on top of a full dump, so shipped ones older than the oldest
+// retained dump of every database on the server are removed. Returns the shipped names still kept.
+func PruneBinlogs(handler *Handler, server BinlogServer, shipped []string) []string {
+	oldest := ""
+	for _, database := range handler.Config.Backup.Binlog.Databases {
+		credentials, _, ok := GetDatabaseCredentials(handler, database)
+		if !ok || GetBinlogServerKey(credentials) != server.Key {
+			continue
+		}
+
+		// Backups are sorted newest first
+		databaseOldest := ""
+		backups := GetLocalBackups(handler, database)
+		for i := len(backups) - 1; i >= 0; i-- {
+			coordinates, err := GetBackupBinlogCoordinates(backups[i].Path)
+			if err == nil {
+				databaseOldest = coordinates.File
+				break
+			}
+		}
+		if databaseOldest == "" {
+			// Without a dump to start from nothing can be pruned safely
+			return shipped
+		}
+		if oldest == "" || databaseOldest < oldest {
+			oldest = databaseOldest
+		}
+	}
+	if oldest == "" {
+		return shipped
+	}
+
+	isShipped := make(map[string]bool)
+	for _, name := range shipped {
+		isShipped[name] = true
+	}
+	kept := make(map[string]bool)
+	for _, name := range GetLocalBinlogs(handler, server.Key) {
+		if name >= oldest || !isShipped[name] {
+			kept[name] = true
+			continue
+		}
+		err := os.Remove(filepath.Join(GetBinlogDirectory(handler, server.Key), name))
+		if err != nil {
+			SleepyWarnLn("Failed to remove old binlog! (%s)", err.Error())
+			kept[name] = true
+			continue
+		}
+		SleepyLogLn("Removed old binlog! (server: %s, name: %s)", server.Key, name)
+	}
+
+	remaining := []string{}
+	for _, name := range shipped {
+		if kept[name] {
+			remaining = append(remaining, name)
+		}
+	}
+
+	return remaining
+}
+
+func GetBackupBinlogCoordinates(path string) (BinlogCoordinates, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return BinlogCoordinates{}, err
+	}
+	defer file.Close()
+
+	// The coordinates are written in the dump header, before any table data
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for i := 0; i < 100 && scanner.Scan(); i++ {
+		match := BinlogCoordinatesRegex.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		position, _ := strconv.ParseUint(match[2], 10, 64)
+		return BinlogCoordinates{
+			File:     match[1],
+			Position: position,
+		}, nil
+	}
+
+	return BinlogCoordinates{}, errors.New("dump has no binlog coordinates")
+}
+
+func RequestDatabaseRestore(handler *Handler, message WebsocketRequestDatabaseRestoreMessage) {
+	taskProgressMessage := WebsocketTaskProgressMessage{
+		Type:   WebsocketMessageTypeTaskProgress,
+		ID:     message.Task,
+		Status: TaskStatusRunning,
+	}
+	err := RestoreDatabase(handler, message, func(progress float32) {
+		taskProgressMessage.Progress = progress
+		SendWebsocketMessage(handler, taskProgressMessage)
+	})
+	if err != nil {
+		SleepyWarnLn("Failed to restore database! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Restore of "+message.Database, err)
+		taskProgressMessage.Status = TaskStatusFailed
+		SendWebsocketMessage(handler, taskProgressMessage)
+		return
+	}
+	taskProgressMessage.Status = TaskStatusFinished
+	taskProgressMessage.Progress = 100
+	SendWebsocketMessage(handler, taskProgressMessage)
+}
+
+func RestoreDatabase(handler *Handler, message WebsocketRequestDatabaseRestoreMessage, progress func(float32)) error {
+	credentials, localDatabase, ok := GetDatabaseCredentials(handler, message.Database)
+	if !ok {
+		return errors.New("database isn't specified in the config")
+	}
+	client := GetMySQLClient()
+	if client == "" {
+		return errors.New("could not find 'mysql'")
+	}
+	executable := GetMySQLBinlog()
+	if executable == "" {
+		return errors.New("could not find 'mysqlbinlog'")
+	}
+	// Never replay over the live database unless the server explicitly asks for it
+	targetName := fmt.Sprintf("%s_restore_%s", localDatabase.Name, time.Now().Format(BackupTimestampFormat))
+	if message.Target != nil && *message.Target != "" {
+		targetName = *message.Target
+	}
+	stop := time.Unix(message.Timestamp, 0)
+
+	// Find the newest full dump taken before the requested time that knows its binlog position
+	var dump LocalBackupFile
+	var coordinates BinlogCoordinates
+	for _, backup := range GetLocalBackups(handler, message.Database) {
+		if backup.Creation.After(stop) {
+			continue
+		}
+		backupCoordinates, err := GetBackupBinlogCoordinates(backup.Path)
+		if err != nil {
+			continue
+		}
+		dump, coordinates = backup, backupCoordinates
+		break
+	}
+	if dump.Path == "" {
+		return fmt.Errorf("no full backup with binlog coordinates before %s", stop.Format(time.RFC3339))
+	}
+	key := GetBinlogServerKey(credentials)
+	binlogs := []string{}
+	for _, name := range GetLocalBinlogs(handler, key) {
+		if name >= coordinates.File {
+			binlogs = append(binlogs, filepath.Join(GetBinlogDirectory(handler, key), name))
+		}
+	}
+	if len(binlogs) == 0 || filepath.Base(binlogs[0]) != coordinates.File {
+		return fmt.Errorf("binlog %s isn't archived", coordinates.File)
+	}
+	SleepyLogLn("Restoring database... (database: %s, target: %s, dump: %s, binlogs: %d)", localDatabase.Name, targetName, filepath.Base(dump.Path), len(binlogs))
+
+	target := MySQLTarget{
+		Executable: client,
+		Args:       []string{"-h", credentials.Host, "-P", credentials.Port, "-u", credentials.Username, "-p" + credentials.Password},
+	}
+	_, err := target.Run(nil, "-e", fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", strings.ReplaceAll(targetName, "`", "``")))
+	if err != nil {
+		return fmt.Errorf("failed to create target database: %s", err.Error())
+	}
+	err = target.Load(targetName, dump.Path)
+	if err != nil {
+		return fmt.Errorf("failed to load dump: %s", err.Error())
+	}
+	progress(50)
+
+	// mysqlbinlog reads --stop-datetime in local time, and filters by --database after rewriting.
+	// The events already carry GTIDs the server executed, so it would skip all of them without --skip-gtids.
+	args := []string{
+		"--start-position=" + strconv.FormatUint(coordinates.Position, 10),
+		"--stop-datetime=" + stop.Local().Format("2006-01-02 15:04:05"),
+		"--database=" + targetName,
+		"--skip-gtids",
+	}
+	if targetName != localDatabase.Name {
+		args = append(args, fmt.Sprintf("--rewrite-db=%s->%s", localDatabase.Name, targetName))
+	}
+	args = append(args, binlogs...)
+	binlogCmd := exec.Command(executable, args...)
+	pipe, err := binlogCmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	err = binlogCmd.Start()
+	if err != nil {
+		return err
+	}
+	counter := &BinlogEventCounter{}
+	_, err = target.Run(io.TeeReader(pipe, counter), targetName)
+	io.Copy(io.Discard, pipe)
+	waitErr := binlogCmd.Wait()
+	if err != nil {
+		return fmt.Errorf("failed to replay binlogs: %s", err.Error())
+	}
+	if waitErr != nil {
+		return fmt.Errorf("failed to read binlogs: %s", waitErr.Error())
+	}
+	if counter.Events == 0 {
+		return fmt.Errorf("no binlog events of %s were replayed", localDatabase.Name)
+	}
+	SleepyLogLn("Restored database! (database: %s, target: %s, until: %s)", localDatabase.Name, targetName, stop.Format(time.RFC3339))
+
+	return nil
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parts are at least this large, files up to it are sent with a single PUT
+var S3MultipartPartSize int64 = 64 * 1024 * 1024
+
+const S3MultipartMaxParts = 10000
+
+const S3MaxResponseSize = 1024 * 1024
+
+const (
+	BackupDestinationTypeAPI   string = "api"
+	BackupDestinationTypeLocal string = "local"
+	BackupDestinationTypeS3    string = "s3"
+	BackupDestinationTypeSFTP  string = "sftp"
+)
+
+type BackupDestination interface {
+	// Stores the file at path, data is the upload metadata that describes it.
+	Store(handler *Handler, path string, data any) error
+}
+
+type APIBackupDestination struct{}
+
+type LocalBackupDestination struct {
+	Path string
+}
+
+type S3BackupDestination struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+}
+
+type SFTPBackupDestination struct {
+	Host     string
+	Port     string
+	Username string
+	KeyFile  string
+	Path     string
+}
+
+// An empty id selects the API upload, which is also what servers without destinations configured expect.
+func GetBackupDestination(handler *Handler, id string) (BackupDestination, error) {
+	if id == "" || id == BackupDestinationTypeAPI {
+		return APIBackupDestination{}, nil
+	}
+	for _, config := range handler.Config.Backup.Destinations {
+		if config.ID != id {
+			continue
+		}
+		switch config.Type {
+		case BackupDestinationTypeAPI:
+			return APIBackupDestination{}, nil
+		case BackupDestinationTypeLocal:
+			return LocalBackupDestination{
+				Path: config.Path,
+			}, nil
+		case BackupDestinationTypeS3:
+			destination := S3BackupDestination{
+				Endpoint:  config.Endpoint,
+				Region:    config.Region,
+				Bucket:    config.Bucket,
+				Prefix:    config.Path,
+				AccessKey: config.AccessKey,
+			}
+			for _, credentials := range handler.Credentials.Destinations {
+				if credentials.ID == config.ID {
+					destination.SecretKey = credentials.SecretKey
+				}
+			}
+			return destination, nil
+		case BackupDestinationTypeSFTP:
+			return SFTPBackupDestination{
+				Host:     config.Host,
+				Port:     config.Port,
+				Username: config.Username,
+				KeyFile:  config.KeyFile,
+				Path:     config.Path,
+			}, nil
+		default:
+			return nil, fmt.Errorf("unknown destination type: %s", config.Type)
+		}
+	}
+
+	return nil, fmt.Errorf("destination isn't specified in the config: %s", id)
+}
+
+func StoreBackup(handler *Handler, destinationID string, path string, data any) error {
+	destination, err := GetBackupDestination(handler, destinationID)
+	if err != nil {
+		return err
+	}
+
+	return destination.Store(handler, path, data)
+}
+
+func (destination APIBackupDestination) Store(handler *Handler, path string, data any) error {
+	return UploadFile(handler, path, data)
+}
+
+// Metadata is written next to the backup as <name>.json, since only the API upload has a place for it.
+func (destination LocalBackupDestination) Store(handler *Handler, path string, data any) error {
+	dataRaw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(destination.Path, 0755)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	target := filepath.Join(destination.Path, filepath.Base(path))
+	out, err := os.Create(target + ".partial")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	out.Close()
+	if err != nil {
+		os.Remove(target + ".partial")
+		return err
+	}
+	err = os.Rename(target+".partial", target)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(target+".json", dataRaw, 0644)
+}
+
+func (destination S3BackupDestination) Store(handler *Handler, path string, data any) error {
+	dataRaw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	key := strings.TrimPrefix(filepath.Base(path), "/")
+	if destination.Prefix != "" {
+		key = strings.Trim(destination.Prefix, "/") + "/" + key
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > S3MultipartPartSize {
+		err = destination.PutMultipartObject(key, file, info.Size())
+	} else {
+		err = destination.PutObject(key, file)
+	}
+	if err != nil {
+		return err
+	}
+
+	return destination.PutObject(key+".json", bytes.NewReader(dataRaw))
+}
+
+func (destination S3BackupDestination) PutObject(key string, body io.ReadSeeker) error {
+	_, _, err := destination.Do("PUT", key, url.Values{}, body)
+	return err
+}
+
+// A single PUT is limited to 5 GB, so larger files are sent in parts of at least S3MultipartPartSize.
+func (destination S3BackupDestination) PutMultipartObject(key string, file io.ReaderAt, size int64) error {
+	resBody, _, err := destination.Do("POST", key, url.Values{"uploads": {""}}, bytes.NewReader(nil))
+	if err != nil {
+		return err
+	}
+	var upload struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.Unmarshal(resBody, &upload)
+	if err != nil {
+		return err
+	}
+	if upload.UploadID == "" {
+		return errors.New("no upload id in the response")
+	}
+
+	err = destination.PutObjectParts(key, upload.UploadID, file, size)
+	if err != nil {
+		_, _, abortErr := destination.Do("DELETE", key, url.Values{"uploadId": {upload.UploadID}}, bytes.NewReader(nil))
+		if abortErr != nil {
+			SleepyWarnLn("Failed to abort a multipart upload! (%s)", abortErr.Error())
+		}
+	}
+
+	return err
+}
+
+type S3CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+func (destination S3BackupDestination) PutObjectParts(key string, uploadID string, file io.ReaderAt, size int64) error {
+	// S3 allows at most 10000 parts
+	partSize := S3MultipartPartSize
+	if size/partSize >= S3MultipartMaxParts {
+		partSize = size/S3MultipartMaxParts + 1
+	}
+
+	var complete struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []S3CompletedPart `xml:"Part"`
+	}
+	for offset := int64(0); offset < size; offset += partSize {
+		number := len(complete.Parts) + 1
+		part := io.NewSectionReader(file, offset, partSize)
+		_, header, err := destination.Do("PUT", key, url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}, part)
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d: %s", number, err.Error())
+		}
+		complete.Parts = append(complete.Parts, S3CompletedPart{number, header.Get("ETag")})
+	}
+	completeRaw, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+
+	// Completing can still fail after a 200, the error is then in the body
+	resBody, _, err := destination.Do("POST", key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(completeRaw))
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(resBody, []byte("<Error>")) {
+		return fmt.Errorf("failed to complete upload: %s", strings.TrimSpace(string(resBody)))
+	}
+
+	return nil
+}
+
+// Sends a signed request for the object at key and returns the response body and headers.
+func (destination S3BackupDestination) Do(method string, key string, query url.Values, body io.ReadSeeker) ([]byte, http.Header, error) {
+	// Hash the payload first, then rewind and send it
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = body.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endpoint := destination.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	req, err := http.NewRequest(method, endpoint+S3EncodePath("/"+destination.Bucket+"/"+key), io.NopCloser(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.URL.RawQuery = S3EncodeQuery(query)
+	req.ContentLength = size
+	destination.Sign(req, hex.EncodeToString(hash.Sum(nil)), time.Now())
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, S3MaxResponseSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		return nil, nil, fmt.Errorf("bad status: %s (%s)", res.Status, strings.TrimSpace(string(resBody)))
+	}
+
+	return resBody, res.Header, nil
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (destination S3BackupDestination) Sign(req *http.Request, payloadHash string, now time.Time) {
+	region := destination.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format("20060102"), region)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		S3EncodePath(req.URL.Path),
+		S3EncodeQuery(req.URL.Query()),
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, amzDate),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+	signingKey := HMACSHA256([]byte("AWS4"+destination.SecretKey), now.Format("20060102"))
+	signingKey = HMACSHA256(signingKey, region)
+	signingKey = HMACSHA256(signingKey, "s3")
+	signingKey = HMACSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(HMACSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", destination.AccessKey, scope, signedHeaders, signature))
+}
+
+func S3EncodePath(raw string) string {
+	return S3Encode(raw, true)
+}
+
+// Keys are sorted and values without one still get the =, as the canonical request wants them.
+func S3EncodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, S3Encode(key, false)+"="+S3Encode(value, false))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+func S3Encode(raw string, keepSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(raw) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' || (keepSlash && b == '/') {
+			encoded.WriteByte(b)
+		} else {
+			encoded.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+
+	return encoded.String()
+}
+
+func HMACSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Uses the system sftp client in batch mode, so only key authentication is supported.
+func (destination SFTPBackupDestination) Store(handler *Handler, localPath string, data any) error {
+	dataRaw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	dataPath := localPath + ".json"
+	err = os.WriteFile(dataPath, dataRaw, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dataPath)
+
+	port := destination.Port
+	if port == "" {
+		port = "22"
+	}
+	args := []string{"-b", "-", "-P", port, "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=accept-new"}
+	if destination.KeyFile != "" {
+		args = append(args, "-i", destination.KeyFile)
+	}
+	args = append(args, fmt.Sprintf("%s@%s", destination.Username, destination.Host))
+
+	cmd := exec.Command("sftp", args...)
+	cmd.Stdin = strings.NewReader(destination.GetBatch(localPath, dataPath))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s (%s)", err.Error(), strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// sftp's mkdir only creates the last component, so every parent is created first. Failures are ignored with "-", as they already exist.
+func (destination SFTPBackupDestination) GetBatch(localPath string, dataPath string) string {
+	var batch strings.Builder
+	if destination.Path != "" {
+		parts := strings.Split(path.Clean(destination.Path), "/")
+		for i := range parts {
+			dir := strings.Join(parts[:i+1], "/")
+			if dir == "" || dir == "." || dir == "~" {
+				continue
+			}
+			batch.WriteString(fmt.Sprintf("-mkdir %s\n", SFTPQuote(dir)))
+		}
+	}
+	remotePath := path.Join(destination.Path, filepath.Base(localPath))
+	batch.WriteString(fmt.Sprintf("put %s %s\nput %s %s\n", SFTPQuote(localPath), SFTPQuote(remotePath), SFTPQuote(dataPath), SFTPQuote(remotePath+".json")))
+
+	return batch.String()
+}
+
+func SFTPQuote(raw string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(raw, `\`, `\\`), `"`, `\"`) + `"`
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const BackupTimestampFormat = "20060102-150405"
+
+type DaemonBackupManager struct {
+	Mutex     *sync.Mutex
+	Pending   []DaemonBackupItem
+	Uploading bool
+	Binlogs   map[string]*exec.Cmd
+}
+
+type DaemonBackupItem struct {
+	Database     string              `json:"database"`
+	Destination  string              `json:"destination"`
+	Path         string              `json:"path"`
+	Creation     int64               `json:"creation"`
+	Verification *BackupVerification `json:"verification"`
+}
+
+type LocalBackupFile struct {
+	Path     string
+	Creation time.Time
+}
+
+func GetBackupDirectory(handler *Handler) string {
+	if handler.Config.Backup.Directory != "" {
+		return handler.Config.Backup.Directory
+	}
+
+	return filepath.Join(handler.Directory, "backups")
+}
+
+func InitBackupManager(handler *Handler) {
+	handler.BackupManager = DaemonBackupManager{
+		Mutex:   &sync.Mutex{},
+		Pending: []DaemonBackupItem{},
+		Binlogs: make(map[string]*exec.Cmd),
+	}
+	raw, err := os.ReadFile(filepath.Join(GetBackupDirectory(handler), "pending.json"))
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(raw, &handler.BackupManager.Pending)
+	if err != nil {
+		SleepyWarnLn("Failed to parse pending backups! (%s)", err.Error())
+		handler.BackupManager.Pending = []DaemonBackupItem{}
+		return
+	}
+	if len(handler.BackupManager.Pending) > 0 {
+		SleepyLogLn("Found %d backups waiting for upload!", len(handler.BackupManager.Pending))
+	}
+}
+
+func RunBackupScheduler(handler *Handler) {
+	schedules := map[int]CronSchedule{}
+	for i, backupSchedule := range handler.Config.Backup.Schedules {
+		schedule, err := ParseCronSchedule(backupSchedule.Cron)
+		if err != nil {
+			SleepyWarnLn("Failed to parse backup schedule! (database: %s, %s)", backupSchedule.Database, err.Error())
+			continue
+		}
+		schedules[i] = schedule
+	}
+	if len(schedules) == 0 {
+		return
+	}
+	SleepyLogLn("Started backup scheduler! (schedules: %d)", len(schedules))
+
+	for {
+		next := time.Now().Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(time.Until(next))
+		for i, schedule := range schedules {
+			if schedule.Matches(next) {
+				go RunScheduledBackup(handler, handler.Config.Backup.Schedules[i])
+			}
+		}
+	}
+}
+
+func RunScheduledBackup(handler *Handler, schedule ConfigBackupSchedule) {
+	SleepyLogLn("Running scheduled backup... (database: %s)", schedule.Database)
+	var path string
+	var err error
+	if schedule.Data {
+		path, err = CreateBackup(handler, schedule.Database)
+	} else {
+		path, err = CreateBackup(handler, schedule.Database, "--no-data")
+	}
+	if err != nil {
+		SleepyWarnLn("Failed to create a scheduled database backup! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Scheduled backup of "+schedule.Database, err)
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var verification *BackupVerification
+	if schedule.Verify {
+		result := VerifyBackup(handler, schedule.Database, path)
+		verification = &result
+	}
+
+	item, err := StoreLocalBackup(handler, schedule.Database, path)
+	if err != nil {
+		SleepyWarnLn("Failed to store a scheduled database backup! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Scheduled backup of "+schedule.Database, err)
+		return
+	}
+	item.Destination = schedule.Destination
+	item.Verification = verification
+	ApplyBackupRetention(handler, schedule.Database, schedule.Retention)
+	QueueBackupUpload(handler, item)
+}
+
+func StoreLocalBackup(handler *Handler, database string, path string) (DaemonBackupItem, error) {
+	creation := time.Now()
+	directory := filepath.Join(GetBackupDirectory(handler), database)
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return DaemonBackupItem{}, err
+	}
+
+	target := filepath.Join(directory, GetTimestampedBackupName(path, creation))
+	err = MoveFile(path, target)
+	if err != nil {
+		return DaemonBackupItem{}, err
+	}
+
+	return DaemonBackupItem{
+		Database: database,
+		Path:     target,
+		Creation: creation.Unix(),
+	}, nil
+}
+
+func GetTimestampedBackupName(path string, creation time.Time) string {
+	extension := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), extension)
+
+	return fmt.Sprintf("%s-%s%s", name, creation.Format(BackupTimestampFormat), extension)
+}
+
+func GetLocalBackups(handler *Handler, database string) []LocalBackupFile {
+	directory := filepath.Join(GetBackupDirectory(handler), database)
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return []LocalBackupFile{}
+	}
+
+	backups := []LocalBackupFile{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if len(name) < len(BackupTimestampFormat) {
+			continue
+		}
+		creation, err := time.ParseInLocation(BackupTimestampFormat, name[len(name)-len(BackupTimestampFormat):], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, LocalBackupFile{
+			Path:     filepath.Join(directory, entry.Name()),
+			Creation: creation,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Creation.After(backups[j].Creation)
+	})
+
+	return backups
+}
+
+func ApplyBackupRetention(handler *Handler, database string, retention ConfigBackupRetention) {
+	if retention.Daily <= 0 && retention.Weekly <= 0 && retention.Monthly <= 0 {
+		return
+	}
+
+	// Backups are sorted newest first, so the first backup seen in a bucket is the one kept
+	backups := GetLocalBackups(handler, database)
+	keep := make(map[string]bool)
+	buckets := []struct {
+		Limit int
+		Key   func(time.Time) string
+	}{
+		{retention.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{retention.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{retention.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, bucket := range buckets {
+		seen := make(map[string]bool)
+		for _, backup := range backups {
+			if len(seen) >= bucket.Limit {
+				break
+			}
+			key := bucket.Key(backup.Creation)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keep[backup.Path] = true
+		}
+	}
+
+	handler.BackupManager.Mutex.Lock()
+	for _, item := range handler.BackupManager.Pending {
+		keep[item.Path] = true
+	}
+	handler.BackupManager.Mutex.Unlock()
+
+	for _, backup := range backups {
+		if keep[backup.Path] {
+			continue
+		}
+		err := os.Remove(backup.Path)
+		if err != nil {
+			SleepyWarnLn("Failed to remove old backup! (%s)", err.Error())
+			continue
+		}
+		SleepyLogLn("Removed old backup! (path: %s)", backup.Path)
+	}
+}
+
+func QueueBackupUpload(handler *Handler, item DaemonBackupItem) {
+	handler.BackupManager.Mutex.Lock()
+	handler.BackupManager.Pending = append(handler.BackupManager.Pending, item)
+	SavePendingBackups(handler)
+	handler.BackupManager.Mutex.Unlock()
+
+	if GetSession(handler) == nil {
+		SleepyLogLn("Not connected, backup will be uploaded after reconnecting! (path: %s)", item.Path)
+		return
+	}
+	UploadPendingBackups(handler)
+}
+
+func UploadPendingBackups(handler *Handler) {
+	handler.BackupManager.Mutex.Lock()
+	if handler.BackupManager.Uploading || len(handler.BackupManager.Pending) == 0 {
+		handler.BackupManager.Mutex.Unlock()
+		return
+	}
+	handler.BackupManager.Uploading = true
+	pending := append([]DaemonBackupItem{}, handler.BackupManager.Pending...)
+	handler.BackupManager.Mutex.Unlock()
+
+	uploaded := make(map[string]bool)
+	for _, item := range pending {
+		if _, err := os.Stat(item.Path); err != nil {
+			SleepyWarnLn("Dropping missing pending backup! (path: %s)", item.Path)
+			uploaded[item.Path] = true
+			continue
+		}
+		uploadFileData := UploadFileScheduledBackupDatabaseData{
+			Type:         UploadFileDataScheduledBackupDatabase,
+			Database:     item.Database,
+			Creation:     item.Creation,
+			Verification: item.Verification,
+		}
+		err := StoreBackup(handler, item.Destination, item.Path, uploadFileData)
+		if err != nil {
+			SleepyWarnLn("Failed to upload pending backup! (%s)", err.Error())
+			break
+		}
+		uploaded[item.Path] = true
+		SleepyLogLn("Uploaded pending backup! (path: %s)", item.Path)
+	}
+
+	handler.BackupManager.Mutex.Lock()
+	remaining := []DaemonBackupItem{}
+	for _, item := range handler.BackupManager.Pending {
+		if !uploaded[item.Path] {
+			remaining = append(remaining, item)
+		}
+	}
+	handler.BackupManager.Pending = remaining
+	handler.BackupManager.Uploading = false
+	SavePendingBackups(handler)
+	handler.BackupManager.Mutex.Unlock()
+}
+
+// Expects BackupManager.Mutex to be held.
+func SavePendingBackups(handler *Handler) {
+	directory := GetBackupDirectory(handler)
+	os.MkdirAll(directory, 0755)
+	raw, _ := json.Marshal(handler.BackupManager.Pending)
+	err := os.WriteFile(filepath.Join(directory, "pending.json"), raw, 0644)
+	if err != nil {
+		SleepyWarnLn("Failed to save pending backups! (%s)", err.Error())
+	}
+}
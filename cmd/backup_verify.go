@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BackupVerificationModeContainer string = "container"
+	BackupVerificationModeServer    string = "server"
+)
+
+type BackupVerification struct {
+	Passed   bool                      `json:"passed"`
+	Error    *string                   `json:"error"`
+	Duration int64                     `json:"duration"`
+	Tables   []BackupVerificationTable `json:"tables"`
+}
+
+// Expected is the number of rows the dump itself inserts into the table.
+type BackupVerificationTable struct {
+	Name     string `json:"name"`
+	Expected uint64 `json:"expected"`
+	Restored uint64 `json:"restored"`
+}
+
+type MySQLTarget struct {
+	Executable string
+	Args       []string
+}
+
+func (target MySQLTarget) Run(stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.Command(target.Executable, append(append([]string{}, target.Args...), args...)...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s (%s)", err.Error(), strings.TrimSpace(stderr.String()))
+	}
+
+	return string(stdout), nil
+}
+
+func (target MySQLTarget) Query(database string, query string) ([][]string, error) {
+	args := []string{"-N", "-B", "-e", query}
+	if database != "" {
+		args = append(args, database)
+	}
+	stdout, err := target.Run(nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		if line == "" {
+			continue
+		}
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+	return rows, nil
+}
+
+func (target MySQLTarget) Load(database string, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = target.Run(file, database)
+	return err
+}
+
+func (target MySQLTarget) GetTableRowCounts(database string) (map[string]uint64, error) {
+	tables, err := target.Query(database, "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'")
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]uint64)
+	for _, table := range tables {
+		rows, err := target.Query(database, fmt.Sprintf("SELECT COUNT(*) FROM `%s`", strings.ReplaceAll(table[0], "`", "``")))
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) < 1 {
+			return nil, fmt.Errorf("no row count for table %s", table[0])
+		}
+		counts[table[0]], _ = strconv.ParseUint(rows[0][0], 10, 64)
+	}
+
+	return counts, nil
+}
+
+func VerifyBackup(handler *Handler, database string, path string) BackupVerification {
+	start := time.Now()
+	verification, err := VerifyBackupInternal(handler, database, path)
+	verification.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		SleepyWarnLn("Failed to verify database backup! (%s)", err.Error())
+		message := err.Error()
+		verification.Error = &message
+		verification.Passed = false
+		return verification
+	}
+	SleepyLogLn("Verified database backup! (database: %s, tables: %d, took %v ms)", database, len(verification.Tables), verification.Duration)
+
+	return verification
+}
+
+// Counts the rows each INSERT of a mysqldump file adds, tables without any rows are included with 0.
+// mysqldump writes every statement on its own line and escapes line breaks inside values.
+func CountDumpRows(path string) (map[string]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	counts := make(map[string]uint64)
+	reader := bufio.NewReaderSize(file, 1024*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, "CREATE TABLE `") {
+			name, _, _ := strings.Cut(strings.TrimPrefix(line, "CREATE TABLE `"), "` ")
+			counts[strings.ReplaceAll(name, "``", "`")] += 0
+		} else if strings.HasPrefix(line, "INSERT INTO `") {
+			name, values, ok := strings.Cut(strings.TrimPrefix(line, "INSERT INTO `"), "` VALUES ")
+			if !ok {
+				return nil, fmt.Errorf("unexpected insert: %.64s", line)
+			}
+			counts[strings.ReplaceAll(name, "``", "`")] += CountDumpValueRows(values)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
+
+// Counts the top level (...) groups, skipping over quoted strings and their escapes.
+func CountDumpValueRows(values string) uint64 {
+	var rows uint64
+	depth := 0
+	quoted := false
+	for i := 0; i < len(values); i++ {
+		switch c := values[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			if depth == 0 {
+				rows++
+			}
+			depth++
+		case c == ')':
+			depth--
+		}
+	}
+
+	return rows
+}
+
+// Row counts of the live source can't be compared, it keeps changing after the dump's snapshot,
+// so the restored counts are compared with the rows the dump itself inserts.
+func VerifyBackupInternal(handler *Handler, database string, path string) (BackupVerification, error) {
+	verification := BackupVerification{
+		Tables: []BackupVerificationTable{},
+	}
+	if _, _, ok := GetDatabaseCredentials(handler, database); !ok {
+		return verification, errors.New("database isn't specified in the config")
+	}
+
+	expected, err := CountDumpRows(path)
+	if err != nil {
+		return verification, fmt.Errorf("failed to read dump: %s", err.Error())
+	}
+	target, verifyDatabase, cleanup, err := CreateVerificationTarget(handler)
+	if err != nil {
+		return verification, err
+	}
+	defer cleanup()
+
+	err = target.Load(verifyDatabase, path)
+	if err != nil {
+		return verification, fmt.Errorf("failed to load dump: %s", err.Error())
+	}
+	restored, err := target.GetTableRowCounts(verifyDatabase)
+	if err != nil {
+		return verification, fmt.Errorf("failed to count restored rows: %s", err.Error())
+	}
+
+	verification.Passed = true
+	for name, count := range expected {
+		if _, ok := restored[name]; !ok {
+			verification.Passed = false
+			restored[name] = 0
+		}
+		if restored[name] != count {
+			verification.Passed = false
+		}
+	}
+	for name, count := range restored {
+		if expected[name] != count {
+			verification.Passed = false
+		}
+		verification.Tables = append(verification.Tables, BackupVerificationTable{
+			Name:     name,
+			Expected: expected[name],
+			Restored: count,
+		})
+	}
+
+	sort.Slice(verification.Tables, func(i, j int) bool {
+		return verification.Tables[i].Name < verification.Tables[j].Name
+	})
+
+	return verification, nil
+}
+
+func CreateVerificationTarget(handler *Handler) (MySQLTarget, string, func(), error) {
+	config := handler.Config.Backup.Verification
+	name := fmt.Sprintf("sleepy_verify_%d", time.Now().UnixNano())
+	switch config.Mode {
+	case BackupVerificationModeServer:
+		client := GetMySQLClient()
+		if client == "" {
+			return MySQLTarget{}, "", nil, errors.New("could not find 'mysql'")
+		}
+		target := MySQLTarget{
+			Executable: client,
+			Args:       []string{"-h", config.Host, "-P", config.Port, "-u", config.Username, "-p" + handler.Credentials.Verification.Password},
+		}
+		_, err := target.Run(nil, "-e", fmt.Sprintf("CREATE DATABASE `%s`", name))
+		if err != nil {
+			return MySQLTarget{}, "", nil, fmt.Errorf("failed to create verification database: %s", err.Error())
+		}
+		cleanup := func() {
+			_, err := target.Run(nil, "-e", fmt.Sprintf("DROP DATABASE `%s`", name))
+			if err != nil {
+				SleepyWarnLn("Failed to drop verification database! (%s)", err.Error())
+			}
+		}
+
+		return target, name, cleanup, nil
+	default:
+		image := config.Image
+		if image == "" {
+			image = "mysql:8"
+		}
+		password := GetMD5Hash(name)
+		_, err := exec.Command("docker", "run", "-d", "--rm", "--name", name, "-e", "MYSQL_ROOT_PASSWORD="+password, "-e", "MYSQL_DATABASE="+name, image).Output()
+		if err != nil {
+			return MySQLTarget{}, "", nil, fmt.Errorf("failed to start verification container: %s", err.Error())
+		}
+		cleanup := func() {
+			_, err := exec.Command("docker", "rm", "-f", name).Output()
+			if err != nil {
+				SleepyWarnLn("Failed to remove verification container! (%s)", err.Error())
+			}
+		}
+		target := MySQLTarget{
+			Executable: "docker",
+			Args:       []string{"exec", "-i", name, "mysql", "-h127.0.0.1", "-uroot", "-p" + password},
+		}
+
+		// The image runs a temporary server without networking while initializing, so wait for TCP
+		timeout := time.Now().Add(time.Second * 120)
+		for {
+			_, err = target.Run(nil, "-e", "SELECT 1")
+			if err == nil {
+				break
+			}
+			if time.Now().After(timeout) {
+				cleanup()
+				return MySQLTarget{}, "", nil, fmt.Errorf("verification container didn't start: %s", err.Error())
+			}
+			time.Sleep(time.Second * 2)
+		}
+
+		return target, name, cleanup, nil
+	}
+}
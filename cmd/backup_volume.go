@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	VolumeBackupQuiesceStop  string = "STOP"
+	VolumeBackupQuiescePause string = "PAUSE"
+)
+
+var VolumeBackupNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+func RequestVolumeBackup(handler *Handler, message WebsocketRequestVolumeBackupMessage) {
+	taskProgressMessage := WebsocketTaskProgressMessage{
+		Type:   WebsocketMessageTypeTaskProgress,
+		ID:     message.Task,
+		Status: TaskStatusRunning,
+	}
+
+	source, name, err := GetVolumeBackupSource(handler, message)
+	if err == nil && message.Quiesce != "" && message.Quiesce != VolumeBackupQuiesceStop && message.Quiesce != VolumeBackupQuiescePause {
+		err = fmt.Errorf("unknown quiesce mode: %s", message.Quiesce)
+	}
+	if err != nil {
+		SleepyWarnLn("Failed to create a volume backup! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Volume backup", err)
+		taskProgressMessage.Status = TaskStatusFailed
+		SendWebsocketMessage(handler, taskProgressMessage)
+		return
+	}
+
+	// Stop or pause containers using the data, so the archive is consistent
+	containers := GetVolumeBackupContainers(handler, message)
+	if message.Quiesce != "" {
+		resumeAction := ContainerActionStart
+		quiesceAction := ContainerActionStop
+		if message.Quiesce == VolumeBackupQuiescePause {
+			resumeAction = ContainerActionUnpause
+			quiesceAction = ContainerActionPause
+		}
+		quiesced := []Container{}
+		defer func() {
+			for _, container := range quiesced {
+				ProcessActionOnContainer(handler, container, resumeAction)
+			}
+		}()
+		for _, container := range containers {
+			err = ProcessActionOnContainer(handler, container, quiesceAction)
+			if err != nil {
+				err = fmt.Errorf("failed to quiesce container %s: %s", container.Name, err.Error())
+				SleepyWarnLn("Failed to create a volume backup! (%s)", err.Error())
+				NotifyTaskFailure(handler, "Volume backup", err)
+				taskProgressMessage.Status = TaskStatusFailed
+				SendWebsocketMessage(handler, taskProgressMessage)
+				return
+			}
+			quiesced = append(quiesced, container)
+		}
+	}
+
+	path := filepath.Join(handler.Directory, "temp", fmt.Sprintf("%s-%s.tar.gz", name, time.Now().Format(BackupTimestampFormat)))
+	defer os.Remove(path)
+	lastProgress := time.Now()
+	err = TarGz(source, path, func(done int64, total int64) {
+		if time.Since(lastProgress) < time.Second || total == 0 {
+			return
+		}
+		lastProgress = time.Now()
+		taskProgressMessage.Progress = (float32(done) / float32(total)) * 90
+		SendWebsocketMessage(handler, taskProgressMessage)
+	})
+	if err != nil {
+		SleepyWarnLn("Failed to archive volume backup! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Volume backup", err)
+		taskProgressMessage.Status = TaskStatusFailed
+		SendWebsocketMessage(handler, taskProgressMessage)
+		return
+	}
+	taskProgressMessage.Progress = 90
+	SendWebsocketMessage(handler, taskProgressMessage)
+
+	uploadFileData := UploadFileBackupVolumeData{
+		Type:   UploadFileDataBackupVolume,
+		Volume: message.Volume,
+		Path:   message.Path,
+		Task:   message.Task,
+	}
+	err = StoreBackup(handler, message.Destination, path, uploadFileData)
+	if err != nil {
+		SleepyWarnLn("Failed to upload volume backup! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Volume backup", err)
+		taskProgressMessage.Status = TaskStatusFailed
+		SendWebsocketMessage(handler, taskProgressMessage)
+		return
+	}
+	taskProgressMessage.Status = TaskStatusFinished
+	taskProgressMessage.Progress = 100
+	SendWebsocketMessage(handler, taskProgressMessage)
+}
+
+func GetVolumeBackupSource(handler *Handler, message WebsocketRequestVolumeBackupMessage) (string, string, error) {
+	if message.Volume != nil {
+		mountpointStdout, err := exec.Command("docker", "volume", "inspect", "--format", "{{.Mountpoint}}", *message.Volume).Output()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to inspect volume: %s", err.Error())
+		}
+		mountpoint := ConvertDockerPath(handler, strings.TrimSpace(string(mountpointStdout)))
+
+		return mountpoint, VolumeBackupNameRegex.ReplaceAllString(*message.Volume, "_"), nil
+	}
+	if message.Path != nil {
+		path, err := filepath.EvalSymlinks(filepath.Clean(*message.Path))
+		if err != nil {
+			return "", "", err
+		}
+		if !IsVolumeBackupPathAllowed(handler, path) {
+			return "", "", fmt.Errorf("directory isn't allowed in the config: %s", path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", "", err
+		}
+		if !info.IsDir() {
+			return "", "", fmt.Errorf("not a directory: %s", path)
+		}
+
+		return path, VolumeBackupNameRegex.ReplaceAllString(filepath.Base(path), "_"), nil
+	}
+
+	return "", "", errors.New("no volume or path specified")
+}
+
+func IsVolumeBackupPathAllowed(handler *Handler, path string) bool {
+	for _, allowed := range handler.Config.Backup.Directories {
+		rel, err := filepath.Rel(filepath.Clean(allowed), path)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetVolumeBackupContainers(handler *Handler, message WebsocketRequestVolumeBackupMessage) []Container {
+	containers := []Container{}
+	cachedContainers, _ := GetCachedContainers(handler)
+	if len(message.Containers) > 0 {
+		for _, container := range cachedContainers {
+			for _, id := range message.Containers {
+				if container.ID == id && container.Status == "running" {
+					containers = append(containers, container)
+				}
+			}
+		}
+		return containers
+	}
+	if message.Volume == nil {
+		return containers
+	}
+
+	// Default to every running container that mounts the volume
+	idsStdout, err := exec.Command("docker", "ps", "-q", "--no-trunc", "--filter", "volume="+*message.Volume).Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get containers using volume! (%s)", err.Error())
+		return containers
+	}
+	for _, rawID := range strings.Fields(string(idsStdout)) {
+		for _, container := range cachedContainers {
+			if strings.HasPrefix(rawID, container.RawID) {
+				containers = append(containers, container)
+			}
+		}
+	}
+
+	return containers
+}
@@ -1,11 +1,57 @@
 package main
 
 type Config struct {
-	Token            string `json:"token"`
-	DaemonHost       string `json:"daemonHost"`
-	APIHost          string `json:"apiHost"`
-	DataHost         string `json:"dataHost"`
-	ReconnectTimeout uint16 `json:"reconnectTimeout"`
+	Token            string              `json:"token"`
+	DaemonHost       string              `json:"daemonHost"`
+	APIHost          string              `json:"apiHost"`
+	DataHost         string              `json:"dataHost"`
+	ReconnectTimeout uint16              `json:"reconnectTimeout"`
+	Backup           ConfigBackup        `json:"backup"`
+	Network          ConfigNetwork       `json:"network"`
+	Processes        ConfigProcesses     `json:"processes"`
+	Sensors          ConfigSensors       `json:"sensors"`
+	Smart            ConfigSmart         `json:"smart"`
+	History          ConfigHistory       `json:"history"`
+	Metrics          ConfigMetrics       `json:"metrics"`
+	Outputs          []ConfigOutput      `json:"outputs"`
+	Alerts           []ConfigAlertRule   `json:"alerts"`
+	Notifications    ConfigNotifications `json:"notifications"`
+	Probes           []ConfigProbe       `json:"probes"`
+}
+
+type ConfigBackup struct {
+	Directory    string                    `json:"directory"`
+	Schedules    []ConfigBackupSchedule    `json:"schedules"`
+	Verification ConfigBackupVerification  `json:"verification"`
+	Directories  []string                  `json:"directories"`
+	Destinations []ConfigBackupDestination `json:"destinations"`
+	Binlog       ConfigBackupBinlog        `json:"binlog"`
+}
+
+type ConfigBackupSchedule struct {
+	Database    string                `json:"database"`
+	Cron        string                `json:"cron"`
+	Data        bool                  `json:"data"`
+	Verify      bool                  `json:"verify"`
+	Destination string                `json:"destination"`
+	Retention   ConfigBackupRetention `json:"retention"`
+}
+
+// Keeps the newest backup of each of the last N days, weeks and months, all zero keeps everything.
+type ConfigBackupRetention struct {
+	Daily   int `json:"daily"`
+	Weekly  int `json:"weekly"`
+	Monthly int `json:"monthly"`
+}
+
+// Dumps are restored into a throwaway database, either in a temporary container or on an existing server.
+// The server's password is in credentials.json.
+type ConfigBackupVerification struct {
+	Mode     string `json:"mode"`
+	Image    string `json:"image"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
 }
 
 func NewConfig() Config {
@@ -14,12 +60,176 @@ func NewConfig() Config {
 		APIHost:          "localhost:9001",
 		DataHost:         "localhost:455",
 		ReconnectTimeout: 5,
+		Backup: ConfigBackup{
+			Schedules:    []ConfigBackupSchedule{},
+			Directories:  []string{},
+			Destinations: []ConfigBackupDestination{},
+			Binlog: ConfigBackupBinlog{
+				Databases: []string{},
+				Interval:  300,
+			},
+			Verification: ConfigBackupVerification{
+				Mode:  BackupVerificationModeContainer,
+				Image: "mysql:8",
+			},
+		},
+		Network: ConfigNetwork{
+			Include: []string{"*"},
+			Exclude: []string{"lo", "veth*", "docker*", "br-*", "virbr*", "vnet*", "Loopback*"},
+		},
+		Processes: ConfigProcesses{
+			AllowNames: []string{},
+			AllowUsers: []string{},
+			DenyNames:  []string{"systemd*", "init", "kthreadd", "sshd", "dockerd", "containerd*", "sleepy-daemon", "csrss.exe", "wininit.exe", "lsass.exe", "services.exe"},
+			DenyUsers:  []string{},
+		},
+		Sensors: ConfigSensors{
+			SysfsRoot: "/sys",
+		},
+		Smart: ConfigSmart{
+			Enabled:  true,
+			Interval: 1800,
+		},
+		History: ConfigHistory{
+			Enabled:   true,
+			Directory: "",
+		},
+		Metrics: ConfigMetrics{
+			Enabled: false,
+			Listen:  "127.0.0.1:9101",
+		},
+		Outputs: []ConfigOutput{},
+		Alerts:  []ConfigAlertRule{},
+		Notifications: ConfigNotifications{
+			Channels: []ConfigNotificationChannel{},
+			Tasks:    []string{},
+		},
+		Probes: []ConfigProbe{},
 	}
 }
 
+// Path is the directory for local and SFTP destinations and the key prefix for S3.
+// The S3 secret key is in credentials.json.
+type ConfigBackupDestination struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"accessKey"`
+	Host      string `json:"host"`
+	Port      string `json:"port"`
+	Username  string `json:"username"`
+	KeyFile   string `json:"keyFile"`
+}
+
+// Binlogs of the listed databases' servers are archived continuously and shipped every Interval seconds.
+type ConfigBackupBinlog struct {
+	Databases   []string `json:"databases"`
+	Destination string   `json:"destination"`
+	Interval    uint32   `json:"interval"`
+}
+
+// Interfaces are reported if they match an include and no exclude glob.
+type ConfigNetwork struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// Patterns are globs matched against process names and users, a deny match always refuses the action.
+// Processes of root or SYSTEM can only be acted on when an allow pattern matches them.
+type ConfigProcesses struct {
+	AllowNames []string `json:"allowNames"`
+	AllowUsers []string `json:"allowUsers"`
+	DenyNames  []string `json:"denyNames"`
+	DenyUsers  []string `json:"denyUsers"`
+}
+
+// SysfsRoot can point at a copy of /sys, to read sensors from a fixture instead of the host.
+type ConfigSensors struct {
+	SysfsRoot string `json:"sysfsRoot"`
+}
+
+// Interval is in seconds
+type ConfigSmart struct {
+	Enabled  bool   `json:"enabled"`
+	Interval uint32 `json:"interval"`
+}
+
+// Directory defaults to history next to the daemon.
+type ConfigHistory struct {
+	Enabled   bool   `json:"enabled"`
+	Directory string `json:"directory"`
+}
+
+// Listen should stay on a private address, the endpoint has no authentication.
+type ConfigMetrics struct {
+	Enabled bool   `json:"enabled"`
+	Listen  string `json:"listen"`
+}
+
+// Type is influx-http, influx-udp or statsd. Address is the write URL for influx-http and host:port otherwise.
+// The influx-http token is in credentials.json.
+type ConfigOutput struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Address  string `json:"address"`
+	Prefix   string `json:"prefix"`
+	Interval uint32 `json:"interval"`
+}
+
+// Fires once Metric compared to Threshold holds for Duration seconds. Target is a glob matched
+// against the container name, pool name, disk ID or chip/label of a sensor, empty matches all.
+type ConfigAlertRule struct {
+	ID        string   `json:"id"`
+	Metric    string   `json:"metric"`
+	Target    string   `json:"target"`
+	Operator  string   `json:"operator"`
+	Threshold float64  `json:"threshold"`
+	Duration  uint32   `json:"duration"`
+	Severity  string   `json:"severity"`
+	Notify    []string `json:"notify"`
+}
+
+// Tasks lists the channels that hear about failed tasks, like backups and updates.
+type ConfigNotifications struct {
+	Channels []ConfigNotificationChannel `json:"channels"`
+	Tasks    []string                    `json:"tasks"`
+}
+
+// Type is webhook, discord, slack or smtp. The webhook URL or smtp password is in credentials.json.
+type ConfigNotificationChannel struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	Host     string   `json:"host"`
+	Port     string   `json:"port"`
+	Username string   `json:"username"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// Type is http, tcp, udp or ping. Target is a URL for http, host:port for tcp and udp, a host for ping.
+// Interval is in seconds and Timeout in milliseconds. Status, Keyword and Insecure only apply to http.
+type ConfigProbe struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Target   string `json:"target"`
+	Interval uint32 `json:"interval"`
+	Timeout  uint32 `json:"timeout"`
+	Status   int    `json:"status"`
+	Keyword  string `json:"keyword"`
+	Insecure bool   `json:"insecure"`
+	Payload  string `json:"payload"`
+}
+
 type ConfigCredentials struct {
-	Databases []ConfigCredentialsDatabase
-	Smb       []ConfigCredentialsSmbUser
+	Databases     []ConfigCredentialsDatabase
+	Smb           []ConfigCredentialsSmbUser
+	Notifications []ConfigCredentialsNotificationChannel
+	Verification  ConfigCredentialsVerification
+	Destinations  []ConfigCredentialsDestination
+	Outputs       []ConfigCredentialsOutput
 }
 
 type ConfigCredentialsDatabase struct {
@@ -40,9 +250,33 @@ type ConfigCredentialsSmbUser struct {
 	Password string `json:"password"`
 }
 
+type ConfigCredentialsVerification struct {
+	Password string `json:"password"`
+}
+
+type ConfigCredentialsDestination struct {
+	ID        string `json:"id"`
+	SecretKey string `json:"secretKey"`
+}
+
+type ConfigCredentialsOutput struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+}
+
+// URL is used by webhook, discord and slack channels, Password by smtp.
+type ConfigCredentialsNotificationChannel struct {
+	ID       string `json:"id"`
+	URL      string `json:"url"`
+	Password string `json:"password"`
+}
+
 func NewConfigCredentials() ConfigCredentials {
 	return ConfigCredentials{
-		Databases: []ConfigCredentialsDatabase{},
-		Smb:       []ConfigCredentialsSmbUser{},
+		Databases:     []ConfigCredentialsDatabase{},
+		Smb:           []ConfigCredentialsSmbUser{},
+		Notifications: []ConfigCredentialsNotificationChannel{},
+		Destinations:  []ConfigCredentialsDestination{},
+		Outputs:       []ConfigCredentialsOutput{},
 	}
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CronSchedule struct {
+	Minute, Hour, Day, Month, Weekday uint64
+	DayAny, WeekdayAny                bool
+}
+
+type CronField struct {
+	Name     string
+	Min, Max int
+	Ptr      *uint64
+}
+
+var CronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parses a standard 5-field cron expression (minute hour day month weekday).
+func ParseCronSchedule(expression string) (CronSchedule, error) {
+	if alias, ok := CronAliases[strings.TrimSpace(expression)]; ok {
+		expression = alias
+	}
+	parts := strings.Fields(expression)
+	if len(parts) != 5 {
+		return CronSchedule{}, fmt.Errorf("expected 5 fields, got %d", len(parts))
+	}
+
+	var schedule CronSchedule
+	fields := []CronField{
+		{"minute", 0, 59, &schedule.Minute},
+		{"hour", 0, 23, &schedule.Hour},
+		{"day", 1, 31, &schedule.Day},
+		{"month", 1, 12, &schedule.Month},
+		{"weekday", 0, 7, &schedule.Weekday},
+	}
+	for i, field := range fields {
+		bits, err := ParseCronField(parts[i], field.Min, field.Max)
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("invalid %s field: %s", field.Name, err.Error())
+		}
+		*field.Ptr = bits
+	}
+	// 7 is an alias for sunday
+	if schedule.Weekday&(1<<7) != 0 {
+		schedule.Weekday |= 1
+	}
+	schedule.DayAny = strings.HasPrefix(parts[2], "*")
+	schedule.WeekdayAny = strings.HasPrefix(parts[4], "*")
+
+	return schedule, nil
+}
+
+func ParseCronField(raw string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(raw, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step in '%s'", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			n, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("bad value in '%s'", part)
+			}
+			start, end = n, n
+			if len(bounds) == 2 {
+				n, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("bad value in '%s'", part)
+				}
+				end = n
+			} else if step > 1 {
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("'%s' out of range %d-%d", part, min, max)
+		}
+		for n := start; n <= end; n += step {
+			bits |= 1 << uint(n)
+		}
+	}
+
+	return bits, nil
+}
+
+func (schedule CronSchedule) Matches(t time.Time) bool {
+	if schedule.Minute&(1<<uint(t.Minute())) == 0 || schedule.Hour&(1<<uint(t.Hour())) == 0 || schedule.Month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	day := schedule.Day&(1<<uint(t.Day())) != 0
+	weekday := schedule.Weekday&(1<<uint(t.Weekday())) != 0
+	// Same as cron, if both day fields are restricted either one can match
+	if !schedule.DayAny && !schedule.WeekdayAny {
+		return day || weekday
+	}
+
+	return day && weekday
+}
@@ -1,10 +1,10 @@
-package main
-
-import "os/exec"
-
-type DaemonLogManager struct {
-	Containers map[string]DaemonLogItem
-}
-type DaemonLogItem struct {
-	Command *exec.Cmd
-}
+package main
+
+import "os/exec"
+
+type DaemonLogManager struct {
+	Containers map[string]DaemonLogItem
+}
+type DaemonLogItem struct {
+	Command *exec.Cmd
+}
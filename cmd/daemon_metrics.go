@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Message types come from the server, so unknown ones past the limit share a bucket
+const DaemonMessageTypesMax = 64
+const DaemonMessageTypeOther = "other"
+const DaemonMessageTypeInvalid = "invalid"
+
+type DaemonMetricsManager struct {
+	Mutex         *sync.Mutex
+	StartTime     time.Time
+	Connections   uint64
+	BytesSent     uint64
+	BytesReceived uint64
+	Messages      map[string]*DaemonMessageMetrics
+}
+
+type DaemonMessageMetrics struct {
+	Count        uint64
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+type DaemonMetrics struct {
+	Version       string                   `json:"version"`
+	PID           int                      `json:"pid"`
+	Uptime        uint64                   `json:"uptime"`
+	Goroutines    int                      `json:"goroutines"`
+	HeapAlloc     uint64                   `json:"heapAlloc"`
+	HeapInuse     uint64                   `json:"heapInuse"`
+	Sys           uint64                   `json:"sys"`
+	GCCount       uint32                   `json:"gcCount"`
+	GCPauseTotal  float64                  `json:"gcPauseTotal"`
+	GCPauseLast   float64                  `json:"gcPauseLast"`
+	FDs           int                      `json:"fds"`
+	Children      int                      `json:"children"`
+	Reconnects    uint64                   `json:"reconnects"`
+	BytesSent     uint64                   `json:"bytesSent"`
+	BytesReceived uint64                   `json:"bytesReceived"`
+	Messages      []DaemonMessageStatistic `json:"messages"`
+}
+
+// Latencies are in milliseconds, measured from receiving the message until its handler returned.
+type DaemonMessageStatistic struct {
+	Type       string  `json:"type"`
+	Count      uint64  `json:"count"`
+	AvgLatency float64 `json:"avgLatency"`
+	MaxLatency float64 `json:"maxLatency"`
+}
+
+func InitMetricsManager(handler *Handler) {
+	handler.MetricsManager = DaemonMetricsManager{
+		Mutex:     &sync.Mutex{},
+		StartTime: time.Now(),
+		Messages:  make(map[string]*DaemonMessageMetrics),
+	}
+}
+
+func RecordDaemonMessage(handler *Handler, messageType string, size int, latency time.Duration) {
+	handler.MetricsManager.Mutex.Lock()
+	defer handler.MetricsManager.Mutex.Unlock()
+
+	handler.MetricsManager.BytesReceived += uint64(size)
+	metrics, ok := handler.MetricsManager.Messages[messageType]
+	if !ok && len(handler.MetricsManager.Messages) >= DaemonMessageTypesMax {
+		messageType = DaemonMessageTypeOther
+		metrics, ok = handler.MetricsManager.Messages[messageType]
+	}
+	if !ok {
+		metrics = &DaemonMessageMetrics{}
+		handler.MetricsManager.Messages[messageType] = metrics
+	}
+	metrics.Count++
+	metrics.TotalLatency += latency
+	if latency > metrics.MaxLatency {
+		metrics.MaxLatency = latency
+	}
+}
+
+func RecordDaemonBytesSent(handler *Handler, size int) {
+	handler.MetricsManager.Mutex.Lock()
+	handler.MetricsManager.BytesSent += uint64(size)
+	handler.MetricsManager.Mutex.Unlock()
+}
+
+func RecordDaemonConnection(handler *Handler) {
+	handler.MetricsManager.Mutex.Lock()
+	handler.MetricsManager.Connections++
+	handler.MetricsManager.Mutex.Unlock()
+}
+
+func GetDaemonMetrics(handler *Handler) DaemonMetrics {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	metrics := DaemonMetrics{
+		Version:      DaemonVersion,
+		PID:          os.Getpid(),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    memStats.HeapAlloc,
+		HeapInuse:    memStats.HeapInuse,
+		Sys:          memStats.Sys,
+		GCCount:      memStats.NumGC,
+		GCPauseTotal: float64(memStats.PauseTotalNs) / float64(time.Millisecond),
+		GCPauseLast:  float64(memStats.PauseNs[(memStats.NumGC+255)%256]) / float64(time.Millisecond),
+		Messages:     []DaemonMessageStatistic{},
+	}
+	switch runtime.GOOS {
+	case "linux", "windows":
+		metrics.FDs, metrics.Children = GetDaemonResourcesSystem()
+	}
+
+	handler.MetricsManager.Mutex.Lock()
+	metrics.Uptime = uint64(time.Since(handler.MetricsManager.StartTime).Seconds())
+	if handler.MetricsManager.Connections > 0 {
+		metrics.Reconnects = handler.MetricsManager.Connections - 1
+	}
+	metrics.BytesSent = handler.MetricsManager.BytesSent
+	metrics.BytesReceived = handler.MetricsManager.BytesReceived
+	for messageType, messageMetrics := range handler.MetricsManager.Messages {
+		metrics.Messages = append(metrics.Messages, DaemonMessageStatistic{
+			Type:       messageType,
+			Count:      messageMetrics.Count,
+			AvgLatency: float64(messageMetrics.TotalLatency) / float64(messageMetrics.Count) / float64(time.Millisecond),
+			MaxLatency: float64(messageMetrics.MaxLatency) / float64(time.Millisecond),
+		})
+	}
+	handler.MetricsManager.Mutex.Unlock()
+	sort.Slice(metrics.Messages, func(i, j int) bool {
+		return metrics.Messages[i].Type < metrics.Messages[j].Type
+	})
+
+	return metrics
+}
@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Returns the number of open file descriptors and direct child processes.
+func GetDaemonResourcesSystem() (int, int) {
+	fds := 0
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		fds = len(entries)
+	}
+
+	children := 0
+	pid := strconv.Itoa(os.Getpid())
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return fds, children
+	}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		nameEnd := bytes.LastIndexByte(stat, ')')
+		if nameEnd < 0 {
+			continue
+		}
+		fields := strings.Fields(string(stat[nameEnd+1:]))
+		if len(fields) > 1 && fields[1] == pid {
+			children++
+		}
+	}
+
+	return fds, children
+}
@@ -0,0 +1,37 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// Windows has handles instead of file descriptors, which also count events, threads and such.
+func GetDaemonResourcesSystem() (int, int) {
+	handles := 0
+	process, err := syscall.GetCurrentProcess()
+	if err == nil {
+		var count uint32
+		ret, _, _ := getProcessHandleCount.Call(uintptr(process), uintptr(unsafe.Pointer(&count)))
+		if ret != 0 {
+			handles = int(count)
+		}
+	}
+
+	children := 0
+	childrenStdout, err := exec.Command("Powershell", "-Command", fmt.Sprintf("(Get-CimInstance Win32_Process -Filter 'ParentProcessId=%d' | Measure-Object).Count", os.Getpid())).Output()
+	if err == nil {
+		children, _ = strconv.Atoi(strings.TrimSpace(string(childrenStdout)))
+		// The count includes the Powershell process itself
+		children = int(MathMin(int64(children-1), 0))
+	}
+
+	return handles, children
+}
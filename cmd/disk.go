@@ -4,31 +4,73 @@ import (
 	"runtime"
 )
 
+// Transport is sata, nvme, usb, sas... RotationRate is in RPM, 0 for solid state disks and nil if unknown.
+// LegacyID is the ID older versions derived from the partition table, so the server can re-key the disk.
 type Disk struct {
-	ID       string      `json:"id"`
-	Parent   string      `json:"parent"`
-	Name     string      `json:"name"`
-	SSD      bool        `json:"ssd"`
-	PTUUID   *string     `json:"ptuuid"`
-	Size     uint64      `json:"size"`
-	Model    *string     `json:"model"`
-	Children []Partition `json:"children"`
+	ID                 string      `json:"id"`
+	LegacyID           *string     `json:"legacyId"`
+	Parent             string      `json:"parent"`
+	Name               string      `json:"name"`
+	SSD                bool        `json:"ssd"`
+	PTUUID             *string     `json:"ptuuid"`
+	Size               uint64      `json:"size"`
+	Model              *string     `json:"model"`
+	Serial             *string     `json:"serial"`
+	WWN                *string     `json:"wwn"`
+	Transport          *string     `json:"transport"`
+	RotationRate       *uint32     `json:"rotationRate"`
+	LogicalSectorSize  uint32      `json:"logicalSectorSize"`
+	PhysicalSectorSize uint32      `json:"physicalSectorSize"`
+	Smart              *DiskSmart  `json:"smart"`
+	Children           []Partition `json:"children"`
 }
 
+// Kind is the block device type (part, raid1, lvm, crypt...), Children are the devices stacked on top of it.
+// LegacyID is only set when older versions gave the partition a different ID.
 type Partition struct {
-	ID         string  `json:"id"`
-	Name       string  `json:"name"`
-	UUID       *string `json:"uuid"`
-	PartUUID   *string `json:"partuuid"`
-	Type       *string `json:"type"`
-	Size       uint64  `json:"size"`
-	Used       *uint64 `json:"used"`
-	Mountpoint *string `json:"mountpoint"`
-	Flags      uint32  `json:"flags"`
+	ID         string      `json:"id"`
+	LegacyID   *string     `json:"legacyId"`
+	Name       string      `json:"name"`
+	Kind       string      `json:"kind"`
+	UUID       *string     `json:"uuid"`
+	PartUUID   *string     `json:"partuuid"`
+	Type       *string     `json:"type"`
+	Size       uint64      `json:"size"`
+	Used       *uint64     `json:"used"`
+	Mountpoint *string     `json:"mountpoint"`
+	Flags      uint32      `json:"flags"`
+	Children   []Partition `json:"children"`
 }
 
 const PartitionFlagBoot = 1
 
+// Looks for a disk or a nested partition by its device name and returns its ID
+func GetDiskDeviceID(disks []Disk, name string) string {
+	for _, disk := range disks {
+		if disk.Name == name {
+			return disk.ID
+		}
+		if id := GetPartitionDeviceID(disk.Children, name); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+func GetPartitionDeviceID(partitions []Partition, name string) string {
+	for _, partition := range partitions {
+		if partition.Name == name {
+			return partition.ID
+		}
+		if id := GetPartitionDeviceID(partition.Children, name); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
 func GetDisks() []Disk {
 	switch runtime.GOOS {
 	case "linux", "windows":
@@ -1,104 +1,270 @@
-//go:build linux
-// +build linux
-
-package main
-
-import (
-	"encoding/json"
-	"os/exec"
-)
-
-type DisksLinuxRaw struct {
-	Blockdevices []DiskLinuxRaw
-}
-
-type DiskLinuxRaw struct {
-	Type       string
-	PTUUID     *string
-	Name       string
-	Rota       bool
-	Size       uint64
-	Model      *string
-	UUID       *string
-	PartUUID   *string
-	FSType     *string
-	FSSize     *uint64
-	FSUsed     *uint64
-	Mountpoint *string
-	Children   []PartitionLinuxRaw
-}
-
-type PartitionLinuxRaw struct {
-	Type       string
-	PTUUID     *string
-	Name       string
-	Rota       bool
-	Size       uint64
-	Model      *string
-	UUID       *string
-	PartUUID   *string
-	FSType     *string
-	FSSize     *uint64
-	FSUsed     *uint64
-	Mountpoint *string
-}
-
-func GetDisksSystem() []Disk {
-	disksStdout, err := exec.Command("lsblk", "-Jbo", "TYPE,PTUUID,NAME,ROTA,SIZE,MODEL,UUID,PARTUUID,FSTYPE,FSSIZE,FSUSED,MOUNTPOINT").Output()
-	if err != nil {
-		SleepyWarnLn("Failed to get disks! (%s)", err.Error())
-		return []Disk{}
-	}
-
-	var disksRaw DisksLinuxRaw
-	err = json.Unmarshal(disksStdout, &disksRaw)
-	if err != nil {
-		SleepyWarnLn("Failed to parse disks! (%s)", err.Error())
-		return []Disk{}
-	}
-
-	var disks []Disk
-	for _, diskRaw := range disksRaw.Blockdevices {
-		var disk Disk = Disk{
-			Name:   diskRaw.Name,
-			SSD:    !diskRaw.Rota,
-			PTUUID: diskRaw.PTUUID,
-			Size:   diskRaw.Size,
-			Model:  diskRaw.Model,
-		}
-		if diskRaw.PTUUID == nil {
-			if len(diskRaw.Children) < 1 || diskRaw.Children[0].UUID == nil {
-				SleepyWarnLn("Failed to get disk ID! (insufficient permissions?)")
-				continue
-			}
-			disk.ID = GetMD5Hash(*diskRaw.Children[0].UUID)
-		} else {
-			disk.ID = GetMD5Hash(*diskRaw.PTUUID)
-		}
-		disk.Children = ArrayMap(diskRaw.Children, func(partRaw PartitionLinuxRaw) Partition {
-			var part Partition = Partition{
-				Name:       partRaw.Name,
-				UUID:       partRaw.UUID,
-				PartUUID:   partRaw.PartUUID,
-				Type:       partRaw.FSType,
-				Size:       partRaw.Size,
-				Used:       partRaw.FSUsed,
-				Mountpoint: partRaw.Mountpoint,
-			}
-			if partRaw.UUID == nil {
-				part.ID = GetMD5Hash(disk.ID + *partRaw.PartUUID)
-			} else {
-				part.ID = GetMD5Hash(*partRaw.UUID)
-			}
-			if partRaw.FSSize != nil {
-				part.Size = *partRaw.FSSize
-			}
-
-			return part
-		})
-
-		disks = append(disks, disk)
-	}
-
-	return disks
-}
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type DisksLinuxRaw struct {
+	Blockdevices []DiskLinuxRaw
+}
+
+type DiskLinuxRaw struct {
+	Type       string
+	PTUUID     *string
+	Name       string
+	Rota       bool
+	Size       uint64
+	Model      *string
+	Serial     *string
+	WWN        *string
+	Tran       *string
+	LogSec     uint32 `json:"log-sec"`
+	PhySec     uint32 `json:"phy-sec"`
+	UUID       *string
+	PartUUID   *string
+	FSType     *string
+	FSSize     *uint64
+	FSUsed     *uint64
+	Mountpoint *string
+	Children   []PartitionLinuxRaw
+}
+
+type PartitionLinuxRaw struct {
+	Type       string
+	PTUUID     *string
+	Name       string
+	Rota       bool
+	Size       uint64
+	Model      *string
+	Serial     *string
+	WWN        *string
+	Tran       *string
+	LogSec     uint32 `json:"log-sec"`
+	PhySec     uint32 `json:"phy-sec"`
+	UUID       *string
+	PartUUID   *string
+	FSType     *string
+	FSSize     *uint64
+	FSUsed     *uint64
+	Mountpoint *string
+	Children   []PartitionLinuxRaw
+}
+
+func GetDisksSystem() []Disk {
+	disksStdout, err := exec.Command("lsblk", "-Jbo", "TYPE,PTUUID,NAME,ROTA,SIZE,MODEL,SERIAL,WWN,TRAN,LOG-SEC,PHY-SEC,UUID,PARTUUID,FSTYPE,FSSIZE,FSUSED,MOUNTPOINT").Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get disks! (%s)", err.Error())
+		return []Disk{}
+	}
+
+	var disksRaw DisksLinuxRaw
+	err = json.Unmarshal(disksStdout, &disksRaw)
+	if err != nil {
+		SleepyWarnLn("Failed to parse disks! (%s)", err.Error())
+		return []Disk{}
+	}
+
+	diskLinks := GetDiskLinksLinux()
+	var disks []Disk
+	var disksRawKept []DiskLinuxRaw
+	for _, diskRaw := range disksRaw.Blockdevices {
+		if IsVirtualDiskLinux(diskRaw.Name) {
+			continue
+		}
+		var disk Disk = Disk{
+			Name:               diskRaw.Name,
+			SSD:                !diskRaw.Rota,
+			PTUUID:             diskRaw.PTUUID,
+			Size:               diskRaw.Size,
+			Model:              TrimOptionalString(diskRaw.Model),
+			Serial:             TrimOptionalString(diskRaw.Serial),
+			WWN:                TrimOptionalString(diskRaw.WWN),
+			Transport:          diskRaw.Tran,
+			LogicalSectorSize:  diskRaw.LogSec,
+			PhysicalSectorSize: diskRaw.PhySec,
+		}
+		if !diskRaw.Rota {
+			var rotationRate uint32 = 0
+			disk.RotationRate = &rotationRate
+		}
+		// lsblk leaves these empty without udev, the kernel usually still knows them
+		if disk.Serial == nil {
+			disk.Serial = GetOptionalString(ReadSysfsStringLinux(filepath.Join("/sys/block", disk.Name, "device", "serial")))
+		}
+		if disk.WWN == nil {
+			disk.WWN = GetOptionalString(ReadSysfsStringLinux(filepath.Join("/sys/block", disk.Name, "device", "wwid")))
+		}
+		disk.ID = GetDiskIDLinux(disk, diskRaw, diskLinks[disk.Name])
+		disk.LegacyID = GetDiskLegacyIDLinux(diskRaw)
+
+		disks = append(disks, disk)
+		disksRawKept = append(disksRawKept, diskRaw)
+	}
+
+	// Partition IDs are derived from the disk ID, so duplicates have to be sorted out first
+	DisambiguateDiskIDsLinux(disks, diskLinks)
+	for i := range disks {
+		disk := &disks[i]
+		disk.Children = ArrayMap(disksRawKept[i].Children, func(partRaw PartitionLinuxRaw) Partition {
+			return GetPartitionLinux(disk.ID, disk.LegacyID, partRaw)
+		})
+	}
+
+	return disks
+}
+
+// Cheap USB bridges and some enclosures report the same WWN or serial for every disk behind them
+func DisambiguateDiskIDsLinux(disks []Disk, diskLinks map[string][]string) {
+	counts := make(map[string]int)
+	for _, disk := range disks {
+		counts[disk.ID]++
+	}
+
+	for i := range disks {
+		if counts[disks[i].ID] < 2 {
+			continue
+		}
+		SleepyWarnLn("Found disks sharing the same ID, telling them apart by their by-id link or name! (name: %s)", disks[i].Name)
+		if links := diskLinks[disks[i].Name]; len(links) > 0 {
+			disks[i].ID = GetMD5Hash(disks[i].ID + links[0])
+		} else {
+			disks[i].ID = GetMD5Hash(disks[i].ID + disks[i].Name)
+		}
+	}
+}
+
+// Loop devices, ram disks and optical drives have no identity worth tracking
+func IsVirtualDiskLinux(name string) bool {
+	for _, prefix := range []string{"loop", "zram", "ram", "sr"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The ID should survive repartitioning and wiping, so hardware identifiers are preferred over partition table ones
+func GetDiskIDLinux(disk Disk, diskRaw DiskLinuxRaw, links []string) string {
+	if disk.WWN != nil {
+		return GetMD5Hash(NormalizeDiskWWNLinux(*disk.WWN))
+	}
+	if disk.Serial != nil {
+		model := ""
+		if disk.Model != nil {
+			model = *disk.Model
+		}
+		return GetMD5Hash(model + *disk.Serial)
+	}
+	if len(links) > 0 {
+		return GetMD5Hash(links[0])
+	}
+	if diskRaw.PTUUID != nil {
+		return GetMD5Hash(*diskRaw.PTUUID)
+	}
+	// Filesystem directly on the disk
+	if diskRaw.UUID != nil {
+		return GetMD5Hash(*diskRaw.UUID)
+	}
+	if len(diskRaw.Children) > 0 && diskRaw.Children[0].UUID != nil {
+		return GetMD5Hash(*diskRaw.Children[0].UUID)
+	}
+
+	SleepyWarnLn("Failed to get a stable disk ID, falling back to the device name! (name: %s)", disk.Name)
+	return GetMD5Hash(disk.Name)
+}
+
+func GetDiskLegacyIDLinux(diskRaw DiskLinuxRaw) *string {
+	var legacyID string
+	if diskRaw.PTUUID != nil {
+		legacyID = GetMD5Hash(*diskRaw.PTUUID)
+	} else if len(diskRaw.Children) > 0 && diskRaw.Children[0].UUID != nil {
+		legacyID = GetMD5Hash(*diskRaw.Children[0].UUID)
+	} else {
+		return nil
+	}
+
+	return &legacyID
+}
+
+// lsblk prints the WWN as 0x5000..., while sysfs has naa.5000..., so both are reduced to the bare hex
+func NormalizeDiskWWNLinux(wwn string) string {
+	wwn = strings.ToLower(strings.TrimSpace(wwn))
+	for _, prefix := range []string{"0x", "naa.", "eui.", "t10."} {
+		wwn = strings.TrimPrefix(wwn, prefix)
+	}
+
+	return wwn
+}
+
+// Maps device names to their /dev/disk/by-id links, the directory is read sorted so the pick is stable
+func GetDiskLinksLinux() map[string][]string {
+	links := make(map[string][]string)
+	entries, err := os.ReadDir("/dev/disk/by-id")
+	if err != nil {
+		return links
+	}
+
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "-part") {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join("/dev/disk/by-id", entry.Name()))
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(target)
+		links[name] = append(links[name], entry.Name())
+	}
+
+	return links
+}
+
+// Devices stacked on partitions (md arrays, LVM volumes, LUKS...) are kept nested under them.
+// Older versions hashed the filesystem UUID or the legacy disk ID with the PARTUUID, and didn't nest anything.
+func GetPartitionLinux(parentID string, legacyParentID *string, partRaw PartitionLinuxRaw) Partition {
+	var part Partition = Partition{
+		Name:       partRaw.Name,
+		Kind:       partRaw.Type,
+		UUID:       partRaw.UUID,
+		PartUUID:   partRaw.PartUUID,
+		Type:       partRaw.FSType,
+		Size:       partRaw.Size,
+		Used:       partRaw.FSUsed,
+		Mountpoint: partRaw.Mountpoint,
+	}
+	// Members of an md array all carry the array's UUID
+	if partRaw.UUID != nil && (partRaw.FSType == nil || *partRaw.FSType != "linux_raid_member") {
+		part.ID = GetMD5Hash(*partRaw.UUID)
+	} else if partRaw.PartUUID != nil {
+		part.ID = GetMD5Hash(parentID + *partRaw.PartUUID)
+	} else {
+		part.ID = GetMD5Hash(parentID + partRaw.Name)
+	}
+	if legacyParentID != nil {
+		var legacyID string
+		if partRaw.UUID != nil {
+			legacyID = GetMD5Hash(*partRaw.UUID)
+		} else if partRaw.PartUUID != nil {
+			legacyID = GetMD5Hash(*legacyParentID + *partRaw.PartUUID)
+		}
+		if legacyID != "" && legacyID != part.ID {
+			part.LegacyID = &legacyID
+		}
+	}
+	if partRaw.FSSize != nil {
+		part.Size = *partRaw.FSSize
+	}
+	part.Children = ArrayMap(partRaw.Children, func(childRaw PartitionLinuxRaw) Partition {
+		return GetPartitionLinux(part.ID, nil, childRaw)
+	})
+
+	return part
+}
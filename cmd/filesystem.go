@@ -0,0 +1,59 @@
+package main
+
+import (
+	"runtime"
+	"time"
+)
+
+type Filesystem struct {
+	Source     string   `json:"source"`
+	Mountpoint string   `json:"mountpoint"`
+	Root       string   `json:"root"`
+	Type       string   `json:"type"`
+	Options    []string `json:"options"`
+	Size       uint64   `json:"size"`
+	Used       uint64   `json:"used"`
+	Available  uint64   `json:"available"`
+	Inodes     uint64   `json:"inodes"`
+	InodesUsed uint64   `json:"inodesUsed"`
+	InodesFree uint64   `json:"inodesFree"`
+	// Growth since the last request, per second, negative when space was freed
+	UsedRate   float64 `json:"usedRate"`
+	InodesRate float64 `json:"inodesRate"`
+}
+
+type FilesystemRaw struct {
+	Used       uint64
+	InodesUsed uint64
+}
+
+func GetFilesystems(handler *Handler) []Filesystem {
+	var filesystems []Filesystem
+	switch runtime.GOOS {
+	case "linux", "windows":
+		filesystems = GetFilesystemsSystem()
+	default:
+		return []Filesystem{}
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(handler.LastSnapshot.FilesystemsTimestamp).Seconds()
+	rawFilesystems := make(map[string]FilesystemRaw)
+	for i, filesystem := range filesystems {
+		raw := FilesystemRaw{
+			Used:       filesystem.Used,
+			InodesUsed: filesystem.InodesUsed,
+		}
+		rawFilesystems[filesystem.Mountpoint] = raw
+		last, ok := handler.LastSnapshot.RawFilesystems[filesystem.Mountpoint]
+		if !ok || elapsed <= 0 {
+			continue
+		}
+		filesystems[i].UsedRate = (float64(raw.Used) - float64(last.Used)) / elapsed
+		filesystems[i].InodesRate = (float64(raw.InodesUsed) - float64(last.InodesUsed)) / elapsed
+	}
+	handler.LastSnapshot.RawFilesystems = rawFilesystems
+	handler.LastSnapshot.FilesystemsTimestamp = now
+
+	return filesystems
+}
@@ -0,0 +1,143 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Virtual filesystems that don't hold any data
+var FilesystemIgnoredTypesLinux = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "efivarfs": true, "fusectl": true,
+	"hugetlbfs": true, "mqueue": true, "nsfs": true, "proc": true, "pstore": true,
+	"rpc_pipefs": true, "securityfs": true, "selinuxfs": true, "sysfs": true, "tracefs": true,
+}
+
+// A hung network mount would block statfs forever, so give up on it after a while
+const FilesystemStatTimeoutLinux = time.Second * 2
+
+// Mountpoints whose statfs hasn't returned yet, they're skipped so a hung mount only ever holds one thread
+var FilesystemStatPendingLinux = map[string]bool{}
+var FilesystemStatPendingMutexLinux sync.Mutex
+
+func GetFilesystemsSystem() []Filesystem {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		SleepyWarnLn("Failed to get filesystems! (%s)", err.Error())
+		return []Filesystem{}
+	}
+	defer file.Close()
+
+	filesystems := []Filesystem{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		filesystem, ok := ParseMountinfoLineLinux(scanner.Text())
+		if !ok || FilesystemIgnoredTypesLinux[filesystem.Type] {
+			continue
+		}
+		stat, err := StatFilesystemLinux(filesystem.Mountpoint)
+		if err != nil {
+			SleepyWarnLn("Failed to get filesystem usage! (mountpoint: %s, %s)", filesystem.Mountpoint, err.Error())
+			continue
+		}
+		if stat.Blocks == 0 {
+			continue
+		}
+		blockSize := uint64(stat.Frsize)
+		if blockSize == 0 {
+			blockSize = uint64(stat.Bsize)
+		}
+		filesystem.Size = stat.Blocks * blockSize
+		filesystem.Used = (stat.Blocks - stat.Bfree) * blockSize
+		filesystem.Available = stat.Bavail * blockSize
+		filesystem.Inodes = stat.Files
+		filesystem.InodesFree = stat.Ffree
+		filesystem.InodesUsed = stat.Files - stat.Ffree
+		filesystems = append(filesystems, filesystem)
+	}
+
+	return filesystems
+}
+
+// https://man7.org/linux/man-pages/man5/proc.5.html (/proc/pid/mountinfo)
+func ParseMountinfoLineLinux(line string) (Filesystem, bool) {
+	fields := strings.Fields(line)
+	separator := -1
+	for i, field := range fields {
+		if field == "-" {
+			separator = i
+			break
+		}
+	}
+	if separator < 6 || len(fields) < separator+3 {
+		return Filesystem{}, false
+	}
+
+	return Filesystem{
+		Source:     UnescapeMountinfoLinux(fields[separator+2]),
+		Mountpoint: UnescapeMountinfoLinux(fields[4]),
+		Root:       UnescapeMountinfoLinux(fields[3]),
+		Type:       fields[separator+1],
+		Options:    strings.Split(fields[5], ","),
+	}, true
+}
+
+// Spaces, tabs, newlines and backslashes are escaped as octal
+func UnescapeMountinfoLinux(raw string) string {
+	if !strings.Contains(raw, `\`) {
+		return raw
+	}
+	var unescaped strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+3 < len(raw) {
+			if value, err := strconv.ParseUint(raw[i+1:i+4], 8, 8); err == nil {
+				unescaped.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		unescaped.WriteByte(raw[i])
+	}
+
+	return unescaped.String()
+}
+
+func StatFilesystemLinux(path string) (syscall.Statfs_t, error) {
+	type statResult struct {
+		stat syscall.Statfs_t
+		err  error
+	}
+	FilesystemStatPendingMutexLinux.Lock()
+	if FilesystemStatPendingLinux[path] {
+		FilesystemStatPendingMutexLinux.Unlock()
+		return syscall.Statfs_t{}, errors.New("previous stat is still pending")
+	}
+	FilesystemStatPendingLinux[path] = true
+	FilesystemStatPendingMutexLinux.Unlock()
+
+	result := make(chan statResult, 1)
+	go func() {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(path, &stat)
+		FilesystemStatPendingMutexLinux.Lock()
+		delete(FilesystemStatPendingLinux, path)
+		FilesystemStatPendingMutexLinux.Unlock()
+		result <- statResult{stat, err}
+	}()
+
+	select {
+	case res := <-result:
+		return res.stat, res.err
+	case <-time.After(FilesystemStatTimeoutLinux):
+		return syscall.Statfs_t{}, syscall.ETIMEDOUT
+	}
+}
@@ -0,0 +1,56 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+)
+
+type FilesystemWindowsRaw struct {
+	DriveLetter     *string
+	Path            string
+	FileSystem      *string
+	FileSystemLabel string
+	Size            uint64
+	SizeRemaining   uint64
+}
+
+// NTFS has no fixed inode table, so only space is reported
+func GetFilesystemsSystem() []Filesystem {
+	volumesStdout, err := exec.Command("Powershell", "-Command", "Get-Volume | ConvertTo-Json").Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get filesystems! (%s)", err.Error())
+		return []Filesystem{}
+	}
+	var volumesRaw []FilesystemWindowsRaw
+	err = json.Unmarshal(volumesStdout, &volumesRaw)
+	if err != nil {
+		SleepyWarnLn("Failed to parse filesystems! (%s)", err.Error())
+		return []Filesystem{}
+	}
+
+	filesystems := []Filesystem{}
+	for _, volumeRaw := range volumesRaw {
+		if volumeRaw.FileSystem == nil || volumeRaw.Size == 0 {
+			continue
+		}
+		mountpoint := volumeRaw.Path
+		if volumeRaw.DriveLetter != nil {
+			mountpoint = *volumeRaw.DriveLetter + ":\\"
+		}
+		filesystems = append(filesystems, Filesystem{
+			Source:     volumeRaw.Path,
+			Mountpoint: mountpoint,
+			Root:       "\\",
+			Type:       *volumeRaw.FileSystem,
+			Options:    []string{},
+			Size:       volumeRaw.Size,
+			Used:       volumeRaw.Size - volumeRaw.SizeRemaining,
+			Available:  volumeRaw.SizeRemaining,
+		})
+	}
+
+	return filesystems
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Each slot is a unix timestamp in seconds and the averaged value, both 8 bytes
+const HistorySlotSize = 16
+
+const HistorySampleInterval = time.Second * 10
+
+const HistoryPruneInterval = time.Hour
+
+type HistoryTier struct {
+	Name       string
+	Resolution time.Duration
+	Retention  time.Duration
+}
+
+var HistoryTiers = []HistoryTier{
+	{"10s", time.Second * 10, time.Hour * 24},
+	{"1m", time.Minute, time.Hour * 24 * 7},
+	{"1h", time.Hour, time.Hour * 24 * 365},
+}
+
+var HistorySeriesNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type DaemonHistoryManager struct {
+	Mutex   *sync.Mutex
+	Buckets map[string]*HistoryBucket
+}
+
+// Samples are averaged into a bucket until one lands in the next bucket, then it's written out.
+type HistoryBucket struct {
+	Start int64
+	Sum   float64
+	Count uint32
+}
+
+type HistorySeries struct {
+	Name       string       `json:"name"`
+	Resolution uint32       `json:"resolution"`
+	Points     [][2]float64 `json:"points"`
+}
+
+func InitHistoryManager(handler *Handler) {
+	handler.HistoryManager = DaemonHistoryManager{
+		Mutex:   &sync.Mutex{},
+		Buckets: make(map[string]*HistoryBucket),
+	}
+}
+
+func GetHistoryDirectory(handler *Handler) string {
+	if handler.Config.History.Directory != "" {
+		return handler.Config.History.Directory
+	}
+
+	return filepath.Join(handler.Directory, "history")
+}
+
+// Samples stats on its own, so history keeps being recorded while disconnected.
+func RunHistorySampler(handler *Handler) {
+	if !handler.Config.History.Enabled {
+		return
+	}
+	for _, tier := range HistoryTiers {
+		os.MkdirAll(filepath.Join(GetHistoryDirectory(handler), tier.Name), 0755)
+	}
+
+	PruneHistory(handler)
+	lastPrune := time.Now()
+	ticker := time.NewTicker(HistorySampleInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		stats := SampleStats(handler)
+		RecordHistory(handler, time.UnixMilli(stats.Timestamp), GetHistorySeriesValues(stats))
+		if time.Since(lastPrune) >= HistoryPruneInterval {
+			PruneHistory(handler)
+			lastPrune = time.Now()
+		}
+	}
+}
+
+// Removes series that stopped being recorded, like removed disks and containers, once none of their slots are within the retention.
+func PruneHistory(handler *Handler) {
+	handler.HistoryManager.Mutex.Lock()
+	defer handler.HistoryManager.Mutex.Unlock()
+
+	for _, tier := range HistoryTiers {
+		oldest := time.Now().Add(-tier.Retention).Unix()
+		prefix := tier.Name + "/"
+		for key, bucket := range handler.HistoryManager.Buckets {
+			if strings.HasPrefix(key, prefix) && bucket.Start < oldest {
+				delete(handler.HistoryManager.Buckets, key)
+			}
+		}
+		for _, name := range GetHistorySeriesNames(handler, tier) {
+			if _, ok := handler.HistoryManager.Buckets[prefix+name]; ok {
+				continue
+			}
+			path := filepath.Join(GetHistoryDirectory(handler), tier.Name, name+".bin")
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			newest := int64(0)
+			for offset := 0; offset+HistorySlotSize <= len(raw); offset += HistorySlotSize {
+				timestamp := int64(binary.LittleEndian.Uint64(raw[offset : offset+8]))
+				if timestamp > newest {
+					newest = timestamp
+				}
+			}
+			if newest >= oldest {
+				continue
+			}
+			err = os.Remove(path)
+			if err != nil {
+				SleepyWarnLn("Failed to remove history! (series: %s, %s)", prefix+name, err.Error())
+			}
+		}
+	}
+}
+
+func GetHistorySeriesValues(stats WebsocketRequestStatsReplyMessage) map[string]float64 {
+	values := map[string]float64{
+		"cpu.user":      float64(stats.CPU.User),
+		"cpu.system":    float64(stats.CPU.System),
+		"cpu.iowait":    float64(stats.CPU.Iowait),
+		"cpu.load1":     float64(stats.CPU.Load.Load1),
+		"memory.used":   float64(stats.Memory.Used),
+		"memory.cached": float64(stats.Memory.Details.Cached),
+		"memory.swap":   float64(stats.Memory.SwapUsed),
+		"network.rx":    float64(stats.Network.RX),
+		"network.tx":    float64(stats.Network.TX),
+	}
+	if stats.Pressure.CPU != nil {
+		values["pressure.cpu"] = float64(stats.Pressure.CPU.Some.Avg10)
+	}
+	if stats.Pressure.Memory != nil {
+		values["pressure.memory"] = float64(stats.Pressure.Memory.Some.Avg10)
+	}
+	if stats.Pressure.IO != nil {
+		values["pressure.io"] = float64(stats.Pressure.IO.Some.Avg10)
+	}
+	for _, disk := range stats.Disks {
+		values["disk."+disk.Parent+".read"] = float64(disk.Read)
+		values["disk."+disk.Parent+".write"] = float64(disk.Write)
+	}
+	for _, container := range stats.Containers {
+		values["container."+container.Parent+".cpu"] = float64(container.CPU)
+		values["container."+container.Parent+".memory"] = float64(container.Memory)
+	}
+
+	return values
+}
+
+func RecordHistory(handler *Handler, timestamp time.Time, values map[string]float64) {
+	handler.HistoryManager.Mutex.Lock()
+	defer handler.HistoryManager.Mutex.Unlock()
+
+	for name, value := range values {
+		name = HistorySeriesNameRegex.ReplaceAllString(name, "_")
+		for _, tier := range HistoryTiers {
+			resolution := int64(tier.Resolution.Seconds())
+			start := timestamp.Unix() - timestamp.Unix()%resolution
+			key := tier.Name + "/" + name
+			bucket, ok := handler.HistoryManager.Buckets[key]
+			if ok && bucket.Start != start {
+				err := WriteHistorySlot(handler, tier, name, bucket.Start, bucket.Sum/float64(bucket.Count))
+				if err != nil {
+					SleepyWarnLn("Failed to write history! (series: %s, %s)", key, err.Error())
+				}
+				ok = false
+			}
+			if !ok {
+				bucket = &HistoryBucket{Start: start}
+				handler.HistoryManager.Buckets[key] = bucket
+			}
+			bucket.Sum += value
+			bucket.Count++
+		}
+	}
+}
+
+// Writes out unfinished buckets, so a restart doesn't lose them.
+func FlushHistory(handler *Handler) {
+	handler.HistoryManager.Mutex.Lock()
+	defer handler.HistoryManager.Mutex.Unlock()
+
+	for _, tier := range HistoryTiers {
+		prefix := tier.Name + "/"
+		for key, bucket := range handler.HistoryManager.Buckets {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			WriteHistorySlot(handler, tier, strings.TrimPrefix(key, prefix), bucket.Start, bucket.Sum/float64(bucket.Count))
+		}
+	}
+}
+
+func GetHistorySlotCount(tier HistoryTier) int64 {
+	return int64(tier.Retention / tier.Resolution)
+}
+
+func WriteHistorySlot(handler *Handler, tier HistoryTier, name string, start int64, value float64) error {
+	file, err := os.OpenFile(filepath.Join(GetHistoryDirectory(handler), tier.Name, name+".bin"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	slot := make([]byte, HistorySlotSize)
+	binary.LittleEndian.PutUint64(slot[0:8], uint64(start))
+	binary.LittleEndian.PutUint64(slot[8:16], math.Float64bits(value))
+	index := (start / int64(tier.Resolution.Seconds())) % GetHistorySlotCount(tier)
+	_, err = file.WriteAt(slot, index*HistorySlotSize)
+
+	return err
+}
+
+// Picks the finest tier that still covers from, unless a coarser resolution was requested.
+func GetHistoryTier(from time.Time, resolution uint32) HistoryTier {
+	for _, tier := range HistoryTiers {
+		if uint32(tier.Resolution.Seconds()) < resolution {
+			continue
+		}
+		if time.Since(from) <= tier.Retention {
+			return tier
+		}
+	}
+
+	return HistoryTiers[len(HistoryTiers)-1]
+}
+
+func ReadHistorySeries(handler *Handler, tier HistoryTier, name string, from time.Time, to time.Time) (HistorySeries, error) {
+	series := HistorySeries{
+		Name:       name,
+		Resolution: uint32(tier.Resolution.Seconds()),
+		Points:     [][2]float64{},
+	}
+	raw, err := os.ReadFile(filepath.Join(GetHistoryDirectory(handler), tier.Name, HistorySeriesNameRegex.ReplaceAllString(name, "_")+".bin"))
+	if errors.Is(err, os.ErrNotExist) {
+		return series, nil
+	}
+	if err != nil {
+		return series, err
+	}
+
+	// Slots that weren't overwritten for longer than the retention are stale
+	oldest := time.Now().Add(-tier.Retention).Unix()
+	for offset := 0; offset+HistorySlotSize <= len(raw); offset += HistorySlotSize {
+		timestamp := int64(binary.LittleEndian.Uint64(raw[offset : offset+8]))
+		if timestamp == 0 || timestamp < oldest || timestamp < from.Unix() || timestamp > to.Unix() {
+			continue
+		}
+		value := math.Float64frombits(binary.LittleEndian.Uint64(raw[offset+8 : offset+16]))
+		series.Points = append(series.Points, [2]float64{float64(timestamp), value})
+	}
+	sort.Slice(series.Points, func(i, j int) bool {
+		return series.Points[i][0] < series.Points[j][0]
+	})
+
+	return series, nil
+}
+
+func GetHistorySeriesNames(handler *Handler, tier HistoryTier) []string {
+	names := []string{}
+	entries, err := os.ReadDir(filepath.Join(GetHistoryDirectory(handler), tier.Name))
+	if err != nil {
+		return names
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".bin") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".bin"))
+		}
+	}
+
+	return names
+}
+
+func RequestStatsHistory(handler *Handler, message WebsocketRequestStatsHistoryMessage) {
+	to := time.Now()
+	if message.To > 0 {
+		to = time.Unix(message.To, 0)
+	}
+	from := to.Add(-time.Hour)
+	if message.From > 0 {
+		from = time.Unix(message.From, 0)
+	}
+	tier := GetHistoryTier(from, message.Resolution)
+	names := message.Series
+	if len(names) == 0 {
+		names = GetHistorySeriesNames(handler, tier)
+	}
+
+	reply := WebsocketRequestStatsHistoryReplyMessage{
+		Type:   WebsocketMessageTypeRequestStatsHistoryReply,
+		ID:     message.ID,
+		Series: []HistorySeries{},
+	}
+	for _, name := range names {
+		series, err := ReadHistorySeries(handler, tier, name, from, to)
+		if err != nil {
+			SleepyWarnLn("Failed to read history! (series: %s, %s)", name, err.Error())
+			continue
+		}
+		reply.Series = append(reply.Series, series)
+	}
+	SendWebsocketMessage(handler, reply)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+const (
+	HostVirtualizationNone      string = "none"
+	HostVirtualizationVM        string = "vm"
+	HostVirtualizationContainer string = "container"
+)
+
+type HostInfo struct {
+	Hostname       string             `json:"hostname"`
+	OS             HostOS             `json:"os"`
+	Kernel         string             `json:"kernel"`
+	Architecture   string             `json:"architecture"`
+	BootTime       int64              `json:"bootTime"`
+	Uptime         uint64             `json:"uptime"`
+	CPU            HostCPU            `json:"cpu"`
+	Virtualization HostVirtualization `json:"virtualization"`
+	Timezone       HostTimezone       `json:"timezone"`
+}
+
+type HostOS struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	PrettyName string `json:"prettyName"`
+}
+
+type HostCPU struct {
+	Model   string `json:"model"`
+	Sockets uint32 `json:"sockets"`
+	Cores   uint32 `json:"cores"`
+	Threads uint32 `json:"threads"`
+}
+
+type HostVirtualization struct {
+	Type string `json:"type"`
+	// For example docker, lxc, kvm or vmware, empty when it couldn't be told
+	Name string `json:"name"`
+}
+
+type HostTimezone struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+}
+
+func GetHostInfo() HostInfo {
+	var host HostInfo
+	switch runtime.GOOS {
+	case "linux", "windows":
+		host = GetHostInfoSystem()
+	default:
+		host = HostInfo{
+			Architecture: runtime.GOARCH,
+		}
+	}
+	host.Hostname, _ = os.Hostname()
+	if host.BootTime > 0 {
+		host.Uptime = uint64(time.Since(time.Unix(host.BootTime, 0)).Seconds())
+	}
+	if host.Timezone.Name == "" {
+		host.Timezone.Name, _ = time.Now().Zone()
+	}
+	_, host.Timezone.Offset = time.Now().Zone()
+
+	return host
+}
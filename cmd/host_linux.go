@@ -0,0 +1,201 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Matched against the DMI vendor and product names
+var HostVirtualizationVendorsLinux = []struct {
+	Match string
+	Name  string
+}{
+	{"KVM", "kvm"},
+	{"QEMU", "qemu"},
+	{"VMware", "vmware"},
+	{"VirtualBox", "virtualbox"},
+	{"Xen", "xen"},
+	{"Microsoft Corporation Virtual Machine", "hyperv"},
+	{"Amazon EC2", "amazon"},
+	{"Google Compute Engine", "google"},
+	{"Parallels", "parallels"},
+	{"bhyve", "bhyve"},
+}
+
+func GetHostInfoSystem() HostInfo {
+	host := HostInfo{
+		OS:             GetHostOSLinux(),
+		Kernel:         ReadSysfsStringLinux("/proc/sys/kernel/osrelease"),
+		Architecture:   runtime.GOARCH,
+		CPU:            GetHostCPULinux(),
+		Virtualization: GetHostVirtualizationLinux(),
+		Timezone:       GetHostTimezoneLinux(),
+	}
+	if machine, err := exec.Command("uname", "-m").Output(); err == nil {
+		host.Architecture = strings.TrimSpace(string(machine))
+	}
+	host.BootTime = GetHostBootTimeLinux()
+
+	return host
+}
+
+// https://www.freedesktop.org/software/systemd/man/os-release.html
+func GetHostOSLinux() HostOS {
+	hostOS := HostOS{
+		ID:   "linux",
+		Name: "Linux",
+	}
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), "=")
+			if !ok {
+				continue
+			}
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			} else {
+				value = strings.Trim(value, `'"`)
+			}
+			switch key {
+			case "ID":
+				hostOS.ID = value
+			case "NAME":
+				hostOS.Name = value
+			case "VERSION_ID":
+				hostOS.Version = value
+			case "PRETTY_NAME":
+				hostOS.PrettyName = value
+			}
+		}
+		break
+	}
+
+	return hostOS
+}
+
+func GetHostBootTimeLinux() int64 {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			bootTime, _ := strconv.ParseInt(fields[1], 10, 64)
+			return bootTime
+		}
+	}
+
+	return 0
+}
+
+func GetHostCPULinux() HostCPU {
+	cpu := HostCPU{}
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return cpu
+	}
+	defer file.Close()
+
+	sockets := make(map[string]bool)
+	cores := make(map[string]bool)
+	physicalID := "0"
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "processor":
+			cpu.Threads++
+		case "model name", "Hardware":
+			if cpu.Model == "" {
+				cpu.Model = value
+			}
+		case "physical id":
+			physicalID = value
+			sockets[value] = true
+		case "core id":
+			cores[physicalID+"-"+value] = true
+		}
+	}
+
+	// Architectures without topology in cpuinfo (like most ARM boards) count every thread as a core
+	cpu.Sockets = uint32(MathMin(int64(len(sockets)), 1))
+	cpu.Cores = uint32(len(cores))
+	if cpu.Cores == 0 {
+		cpu.Cores = cpu.Threads
+	}
+
+	return cpu
+}
+
+func GetHostVirtualizationLinux() HostVirtualization {
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return HostVirtualization{HostVirtualizationContainer, "docker"}
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return HostVirtualization{HostVirtualizationContainer, "podman"}
+	}
+	if environ, err := os.ReadFile("/proc/1/environ"); err == nil {
+		for _, variable := range strings.Split(string(environ), "\x00") {
+			if strings.HasPrefix(variable, "container=") {
+				return HostVirtualization{HostVirtualizationContainer, strings.TrimPrefix(variable, "container=")}
+			}
+		}
+	}
+	if cgroup, err := os.ReadFile("/proc/1/cgroup"); err == nil {
+		for _, name := range []string{"docker", "lxc", "kubepods"} {
+			if strings.Contains(string(cgroup), name) {
+				return HostVirtualization{HostVirtualizationContainer, name}
+			}
+		}
+	}
+
+	dmi := ReadSysfsStringLinux("/sys/class/dmi/id/sys_vendor") + " " + ReadSysfsStringLinux("/sys/class/dmi/id/product_name")
+	for _, vendor := range HostVirtualizationVendorsLinux {
+		if strings.Contains(dmi, vendor.Match) {
+			return HostVirtualization{HostVirtualizationVM, vendor.Name}
+		}
+	}
+	// The hypervisor flag is set for every guest, even when the vendor is unknown
+	if cpuinfo, err := os.ReadFile("/proc/cpuinfo"); err == nil && strings.Contains(string(cpuinfo), " hypervisor") {
+		return HostVirtualization{HostVirtualizationVM, ""}
+	}
+
+	return HostVirtualization{HostVirtualizationNone, ""}
+}
+
+func GetHostTimezoneLinux() HostTimezone {
+	if timezone := ReadSysfsStringLinux("/etc/timezone"); timezone != "" {
+		return HostTimezone{Name: timezone}
+	}
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return HostTimezone{Name: name}
+		}
+	}
+
+	return HostTimezone{}
+}
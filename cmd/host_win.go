@@ -0,0 +1,89 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+type HostWindowsRaw struct {
+	Caption        string
+	Version        string
+	OSArchitecture string
+	BootTime       int64
+	Processors     []HostProcessorWindowsRaw
+	Manufacturer   string
+	Model          string
+	Timezone       string
+}
+
+type HostProcessorWindowsRaw struct {
+	Name                      string
+	NumberOfCores             uint32
+	NumberOfLogicalProcessors uint32
+}
+
+const HostInfoCommandWindows = `$os = Get-CimInstance Win32_OperatingSystem; $cs = Get-CimInstance Win32_ComputerSystem; @{
+Caption = $os.Caption; Version = $os.Version; OSArchitecture = $os.OSArchitecture;
+BootTime = ([DateTimeOffset]$os.LastBootUpTime).ToUnixTimeSeconds();
+Processors = @(Get-CimInstance Win32_Processor | Select-Object Name,NumberOfCores,NumberOfLogicalProcessors);
+Manufacturer = $cs.Manufacturer; Model = $cs.Model;
+Timezone = (Get-TimeZone).Id } | ConvertTo-Json -Depth 3`
+
+func GetHostInfoSystem() HostInfo {
+	host := HostInfo{
+		OS: HostOS{
+			ID:   "windows",
+			Name: "Windows",
+		},
+		Architecture: runtime.GOARCH,
+		Virtualization: HostVirtualization{
+			Type: HostVirtualizationNone,
+		},
+	}
+	hostStdout, err := exec.Command("Powershell", "-Command", HostInfoCommandWindows).Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get host info! (%s)", err.Error())
+		return host
+	}
+	var hostRaw HostWindowsRaw
+	err = json.Unmarshal(hostStdout, &hostRaw)
+	if err != nil {
+		SleepyWarnLn("Failed to parse host info! (%s)", err.Error())
+		return host
+	}
+
+	host.OS.Version = hostRaw.Version
+	host.OS.PrettyName = hostRaw.Caption
+	host.Kernel = hostRaw.Version
+	if hostRaw.OSArchitecture != "" {
+		host.Architecture = hostRaw.OSArchitecture
+	}
+	host.BootTime = hostRaw.BootTime
+	host.Timezone.Name = hostRaw.Timezone
+	host.CPU.Sockets = uint32(len(hostRaw.Processors))
+	for _, processor := range hostRaw.Processors {
+		host.CPU.Model = strings.TrimSpace(processor.Name)
+		host.CPU.Cores += processor.NumberOfCores
+		host.CPU.Threads += processor.NumberOfLogicalProcessors
+	}
+
+	// Win32_ComputerSystem.HypervisorPresent is also set on Hyper-V hosts, so only the model tells a guest apart
+	model := hostRaw.Manufacturer + " " + hostRaw.Model
+	switch {
+	case strings.Contains(model, "Virtual Machine"):
+		host.Virtualization = HostVirtualization{HostVirtualizationVM, "hyperv"}
+	case strings.Contains(model, "VMware"):
+		host.Virtualization = HostVirtualization{HostVirtualizationVM, "vmware"}
+	case strings.Contains(model, "VirtualBox"):
+		host.Virtualization = HostVirtualization{HostVirtualizationVM, "virtualbox"}
+	case strings.Contains(model, "QEMU") || strings.Contains(model, "KVM"):
+		host.Virtualization = HostVirtualization{HostVirtualizationVM, "kvm"}
+	}
+
+	return host
+}
@@ -0,0 +1,42 @@
+package main
+
+import "runtime"
+
+// Sizes are in bytes, Children are the IDs of the physical volumes from the disks.
+type LVMVolumeGroup struct {
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Size     uint64              `json:"size"`
+	Free     uint64              `json:"free"`
+	Children []LVMPhysicalVolume `json:"children"`
+	Volumes  []LVMLogicalVolume  `json:"volumes"`
+}
+
+type LVMPhysicalVolume struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Size uint64 `json:"size"`
+	Free uint64 `json:"free"`
+}
+
+// Kind is the segment type (linear, striped, raid1, thin-pool, thin...), Pool is set for thin volumes.
+// Data and Metadata are usage percentages, only reported for thin pools and thin volumes.
+type LVMLogicalVolume struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	Size     uint64   `json:"size"`
+	Pool     *string  `json:"pool"`
+	Data     *float32 `json:"data"`
+	Metadata *float32 `json:"metadata"`
+	Active   bool     `json:"active"`
+}
+
+func GetLVMVolumeGroups(disks []Disk) []LVMVolumeGroup {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetLVMVolumeGroupsSystem(disks)
+	default:
+		return []LVMVolumeGroup{}
+	}
+}
@@ -0,0 +1,134 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lvm reports every value as a string
+type LVMReportLinuxRaw struct {
+	Report []struct {
+		VG []map[string]string `json:"vg"`
+		PV []map[string]string `json:"pv"`
+		LV []map[string]string `json:"lv"`
+	} `json:"report"`
+}
+
+func GetLVMVolumeGroupsSystem(disks []Disk) []LVMVolumeGroup {
+	if _, err := exec.LookPath("vgs"); err != nil {
+		return []LVMVolumeGroup{}
+	}
+
+	vgsRaw, err := GetLVMReportLinux("vgs", "vg_name,vg_uuid,vg_size,vg_free")
+	if err != nil {
+		SleepyWarnLn("Failed to get LVM volume groups! (%s)", err.Error())
+		return []LVMVolumeGroup{}
+	}
+	pvsRaw, err := GetLVMReportLinux("pvs", "pv_name,pv_uuid,vg_name,pv_size,pv_free")
+	if err != nil {
+		SleepyWarnLn("Failed to get LVM physical volumes! (%s)", err.Error())
+		return []LVMVolumeGroup{}
+	}
+	lvsRaw, err := GetLVMReportLinux("lvs", "lv_name,lv_uuid,vg_name,lv_size,segtype,pool_lv,data_percent,metadata_percent,lv_active")
+	if err != nil {
+		SleepyWarnLn("Failed to get LVM logical volumes! (%s)", err.Error())
+		return []LVMVolumeGroup{}
+	}
+
+	groups := []LVMVolumeGroup{}
+	for _, vgRaw := range vgsRaw {
+		group := LVMVolumeGroup{
+			ID:       GetMD5Hash(vgRaw["vg_uuid"]),
+			Name:     vgRaw["vg_name"],
+			Size:     ParseLVMSizeLinux(vgRaw["vg_size"]),
+			Free:     ParseLVMSizeLinux(vgRaw["vg_free"]),
+			Children: []LVMPhysicalVolume{},
+			Volumes:  []LVMLogicalVolume{},
+		}
+		for _, pvRaw := range pvsRaw {
+			if pvRaw["vg_name"] != group.Name {
+				continue
+			}
+			name := filepath.Base(pvRaw["pv_name"])
+			id := GetDiskDeviceID(disks, name)
+			if id == "" {
+				id = GetMD5Hash(pvRaw["pv_uuid"])
+			}
+			group.Children = append(group.Children, LVMPhysicalVolume{
+				ID:   id,
+				Name: name,
+				Size: ParseLVMSizeLinux(pvRaw["pv_size"]),
+				Free: ParseLVMSizeLinux(pvRaw["pv_free"]),
+			})
+		}
+		for _, lvRaw := range lvsRaw {
+			if lvRaw["vg_name"] != group.Name {
+				continue
+			}
+			volume := LVMLogicalVolume{
+				Name:     lvRaw["lv_name"],
+				Kind:     lvRaw["segtype"],
+				Size:     ParseLVMSizeLinux(lvRaw["lv_size"]),
+				Data:     ParseLVMPercentLinux(lvRaw["data_percent"]),
+				Metadata: ParseLVMPercentLinux(lvRaw["metadata_percent"]),
+				Active:   lvRaw["lv_active"] == "active",
+			}
+			if pool := lvRaw["pool_lv"]; pool != "" {
+				volume.Pool = &pool
+			}
+			// Device mapper doubles the dashes inside the names
+			volume.ID = GetDiskDeviceID(disks, strings.ReplaceAll(group.Name, "-", "--")+"-"+strings.ReplaceAll(volume.Name, "-", "--"))
+			if volume.ID == "" {
+				volume.ID = GetMD5Hash(lvRaw["lv_uuid"])
+			}
+			group.Volumes = append(group.Volumes, volume)
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func GetLVMReportLinux(command string, fields string) ([]map[string]string, error) {
+	reportStdout, err := exec.Command(command, "--reportformat", "json", "--units", "b", "--nosuffix", "-o", fields).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var reportRaw LVMReportLinuxRaw
+	err = json.Unmarshal(reportStdout, &reportRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []map[string]string{}
+	for _, report := range reportRaw.Report {
+		rows = append(rows, report.VG...)
+		rows = append(rows, report.PV...)
+		rows = append(rows, report.LV...)
+	}
+
+	return rows, nil
+}
+
+func ParseLVMSizeLinux(value string) uint64 {
+	size, _ := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	return size
+}
+
+func ParseLVMPercentLinux(value string) *float32 {
+	percent, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+	if err != nil {
+		return nil
+	}
+	n := float32(percent)
+
+	return &n
+}
@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package main
+
+func GetLVMVolumeGroupsSystem(disks []Disk) []LVMVolumeGroup {
+	return []LVMVolumeGroup{}
+}
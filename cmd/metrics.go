@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const MetricsPrefix = "sleepy_"
+
+type MetricsWriter struct {
+	Builder  strings.Builder
+	Families map[string]bool
+}
+
+type MetricsSample struct {
+	Labels []string
+	Value  float64
+}
+
+func RunMetricsServer(handler *Handler) {
+	if !handler.Config.Metrics.Enabled {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+		body := GetMetricsText(handler, openMetrics)
+		if openMetrics {
+			w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		}
+		w.Write([]byte(body))
+	})
+
+	SleepyLogLn("Serving metrics on http://%s/metrics...", handler.Config.Metrics.Listen)
+	err := http.ListenAndServe(handler.Config.Metrics.Listen, mux)
+	if err != nil {
+		SleepyWarnLn("Failed to serve metrics! (%s)", err.Error())
+	}
+}
+
+// Writes every sample of a family at once, since OpenMetrics doesn't allow them to be interleaved.
+func (writer *MetricsWriter) Gauge(name string, help string, samples ...MetricsSample) {
+	name = MetricsPrefix + name
+	if !writer.Families[name] {
+		writer.Families[name] = true
+		writer.Builder.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s gauge\n", name, help, name))
+	}
+	for _, sample := range samples {
+		writer.Builder.WriteString(name)
+		if len(sample.Labels) > 0 {
+			labels := []string{}
+			for i := 0; i+1 < len(sample.Labels); i += 2 {
+				labels = append(labels, fmt.Sprintf(`%s="%s"`, sample.Labels[i], EscapeMetricsLabel(sample.Labels[i+1])))
+			}
+			writer.Builder.WriteString("{" + strings.Join(labels, ",") + "}")
+		}
+		writer.Builder.WriteString(" " + strconv.FormatFloat(sample.Value, 'g', -1, 64) + "\n")
+	}
+}
+
+func EscapeMetricsLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Goes through the shortest float32 representation, so 0.1 isn't written as 0.10000000149011612
+func MetricsFloat32(value float32) float64 {
+	parsed, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'g', -1, 32), 64)
+	return parsed
+}
+
+func Sample(value float64, labels ...string) MetricsSample {
+	return MetricsSample{
+		Labels: labels,
+		Value:  value,
+	}
+}
+
+func GetMetricsText(handler *Handler, openMetrics bool) string {
+	stats := GetLatestStats(handler, HistorySampleInterval)
+	writer := MetricsWriter{
+		Families: make(map[string]bool),
+	}
+
+	cpu := stats.CPU
+	writer.Gauge("cpu_usage_percent", "CPU time spent per mode.",
+		Sample(MetricsFloat32(cpu.User), "mode", "user"),
+		Sample(MetricsFloat32(cpu.System), "mode", "system"),
+		Sample(MetricsFloat32(cpu.Nice), "mode", "nice"),
+		Sample(MetricsFloat32(cpu.Iowait), "mode", "iowait"),
+		Sample(MetricsFloat32(cpu.Irq), "mode", "irq"),
+		Sample(MetricsFloat32(cpu.Softirq), "mode", "softirq"),
+		Sample(MetricsFloat32(cpu.Steal), "mode", "steal"),
+	)
+	writer.Gauge("load_average", "System load average.",
+		Sample(MetricsFloat32(cpu.Load.Load1), "period", "1m"),
+		Sample(MetricsFloat32(cpu.Load.Load5), "period", "5m"),
+		Sample(MetricsFloat32(cpu.Load.Load15), "period", "15m"),
+	)
+	writer.Gauge("context_switches_per_second", "Context switches per second.", Sample(float64(cpu.ContextSwitches)))
+	writer.Gauge("interrupts_per_second", "Interrupts per second.", Sample(float64(cpu.Interrupts)))
+
+	memory := stats.Memory.Details
+	writer.Gauge("memory_bytes", "Memory usage in bytes.",
+		Sample(float64(memory.Total), "type", "total"),
+		Sample(float64(memory.Used), "type", "used"),
+		Sample(float64(memory.Free), "type", "free"),
+		Sample(float64(memory.Available), "type", "available"),
+		Sample(float64(memory.Buffers), "type", "buffers"),
+		Sample(float64(memory.Cached), "type", "cached"),
+	)
+	writer.Gauge("swap_bytes", "Swap usage in bytes.",
+		Sample(float64(memory.SwapTotal), "type", "total"),
+		Sample(float64(memory.SwapUsed), "type", "used"),
+	)
+
+	diskRead, diskWrite := []MetricsSample{}, []MetricsSample{}
+	for _, disk := range stats.Disks {
+		diskRead = append(diskRead, Sample(float64(disk.Read), "disk", disk.Parent))
+		diskWrite = append(diskWrite, Sample(float64(disk.Write), "disk", disk.Parent))
+	}
+	writer.Gauge("disk_read_bytes_per_second", "Disk reads in bytes per second.", diskRead...)
+	writer.Gauge("disk_write_bytes_per_second", "Disk writes in bytes per second.", diskWrite...)
+
+	networkRX, networkTX := []MetricsSample{}, []MetricsSample{}
+	for _, networkInterface := range stats.Network.Interfaces {
+		networkRX = append(networkRX, Sample(float64(networkInterface.RX), "interface", networkInterface.Name))
+		networkTX = append(networkTX, Sample(float64(networkInterface.TX), "interface", networkInterface.Name))
+	}
+	writer.Gauge("network_receive_bytes_per_second", "Network traffic received in bytes per second.", networkRX...)
+	writer.Gauge("network_transmit_bytes_per_second", "Network traffic sent in bytes per second.", networkTX...)
+
+	zfsSize, zfsUsed, zfsRatio := []MetricsSample{}, []MetricsSample{}, []MetricsSample{}
+	for _, pool := range GetZFSPools(GetDisks()) {
+		zfsSize = append(zfsSize, Sample(float64(pool.Size), "pool", pool.Name))
+		zfsUsed = append(zfsUsed, Sample(float64(pool.Used), "pool", pool.Name))
+		zfsRatio = append(zfsRatio, Sample(MetricsFloat32(pool.CompressRatio), "pool", pool.Name))
+	}
+	writer.Gauge("zfs_pool_size_bytes", "ZFS pool size in bytes.", zfsSize...)
+	writer.Gauge("zfs_pool_used_bytes", "ZFS pool usage in bytes.", zfsUsed...)
+	writer.Gauge("zfs_pool_compress_ratio", "ZFS pool compression ratio.", zfsRatio...)
+
+	containerFamilies := []struct {
+		Name  string
+		Help  string
+		Value func(usage ContainerUsage) float64
+	}{
+		{"container_cpu_percent", "Container CPU usage.", func(usage ContainerUsage) float64 { return MetricsFloat32(usage.CPU) }},
+		{"container_memory_bytes", "Container memory usage in bytes.", func(usage ContainerUsage) float64 { return float64(usage.Memory) }},
+		{"container_network_receive_bytes_per_second", "Container network traffic received in bytes per second.", func(usage ContainerUsage) float64 { return float64(usage.RX) }},
+		{"container_network_transmit_bytes_per_second", "Container network traffic sent in bytes per second.", func(usage ContainerUsage) float64 { return float64(usage.TX) }},
+		{"container_read_bytes_per_second", "Container block reads in bytes per second.", func(usage ContainerUsage) float64 { return float64(usage.Read) }},
+		{"container_write_bytes_per_second", "Container block writes in bytes per second.", func(usage ContainerUsage) float64 { return float64(usage.Write) }},
+	}
+	containerLabels := GetMetricsContainerLabels(handler)
+	for _, family := range containerFamilies {
+		samples := []MetricsSample{}
+		for _, usage := range stats.Containers {
+			labels, ok := containerLabels[usage.Parent]
+			if !ok {
+				continue
+			}
+			samples = append(samples, Sample(family.Value(usage), labels...))
+		}
+		writer.Gauge(family.Name, family.Help, samples...)
+	}
+
+	if openMetrics {
+		writer.Builder.WriteString("# EOF\n")
+	}
+	return writer.Builder.String()
+}
+
+func GetMetricsContainerLabels(handler *Handler) map[string][]string {
+	containers, containerProjects := GetCachedContainers(handler)
+	projects := make(map[string]string)
+	for _, project := range containerProjects {
+		projects[project.ID] = project.Name
+	}
+	labels := make(map[string][]string)
+	for _, container := range containers {
+		project := ""
+		if container.Parent != nil {
+			project = projects[*container.Parent]
+		}
+		labels[container.ID] = []string{"container", container.Name, "project", project}
+	}
+
+	return labels
+}
+
+// Reuses the last sample if it's recent enough, so scrapes don't skew the rates other consumers see.
+func GetLatestStats(handler *Handler, maxAge time.Duration) WebsocketRequestStatsReplyMessage {
+	handler.StatsManager.Mutex.Lock()
+	last := handler.StatsManager.Last
+	handler.StatsManager.Mutex.Unlock()
+	if last != nil && time.Since(time.UnixMilli(last.Timestamp)) < maxAge {
+		return *last
+	}
+
+	return SampleStats(handler)
+}
@@ -1,58 +1,145 @@
-package main
-
-import (
-	"errors"
-	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"runtime"
-	"strings"
-)
-
-func CreateBackup(handler *Handler, database string, args ...string) (string, error) {
-	executable := GetMySQLDump()
-	if executable == "" {
-		return "", errors.New("could not find 'mysqldump'")
-	}
-	dumpPath := filepath.Join(handler.Directory, "temp")
-	os.MkdirAll(dumpPath, 0755)
-
-	for _, credentials := range handler.Credentials.Databases {
-		for _, localDatabase := range credentials.Databases {
-			if localDatabase.ID == database {
-				path := filepath.Join(dumpPath, localDatabase.Name+".sql")
-				cmdArgs := []string{"-h", credentials.Host, "-P", credentials.Port, "-u", credentials.Username, "-p" + credentials.Password}
-				cmdArgs = append(cmdArgs, args...)
-				cmdArgs = append(cmdArgs, localDatabase.Name, "--result-file="+path)
-				_, err := exec.Command(executable, cmdArgs...).Output()
-				if err != nil {
-					SleepyErrorLn("%v", cmdArgs)
-					return "", err
-				}
-
-				return path, nil
-			}
-		}
-	}
-
-	return "", errors.New("database isn't specified in the config")
-}
-
-func GetMySQLDump() string {
-	return GetMySQLDumpSystem(runtime.GOOS)
-}
-
-func GetMySQLDumpSystem(system string) string {
-	err := exec.Command("mysqldump").Run()
-	if err != nil && strings.Contains(err.Error(), "not found") {
-		err := exec.Command(fmt.Sprintf("tools/%s/mysqldump", system)).Run()
-		if err != nil && strings.Contains(err.Error(), "not found") {
-			return ""
-		}
-
-		return fmt.Sprintf("tools/%s/mysqldump", system)
-	}
-
-	return "mysqldump"
-}
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+func CreateBackup(handler *Handler, database string, args ...string) (string, error) {
+	executable := GetMySQLDump()
+	if executable == "" {
+		return "", errors.New("could not find 'mysqldump'")
+	}
+	credentials, localDatabase, ok := GetDatabaseCredentials(handler, database)
+	if !ok {
+		return "", errors.New("database isn't specified in the config")
+	}
+
+	// Scheduled and requested backups of one database can overlap, so every dump gets its own directory
+	tempPath := filepath.Join(handler.Directory, "temp")
+	os.MkdirAll(tempPath, 0755)
+	dumpPath, err := os.MkdirTemp(tempPath, localDatabase.Name+"-")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dumpPath, localDatabase.Name+".sql")
+	cmdArgs := []string{"-h", credentials.Host, "-P", credentials.Port, "-u", credentials.Username, "-p" + credentials.Password}
+	cmdArgs = append(cmdArgs, args...)
+	if IsBinlogArchived(handler, database) && !slices.Contains(args, "--no-data") {
+		// Records the binlog position in the dump, so restores know where to start replaying
+		cmdArgs = append(cmdArgs, "--single-transaction", "--master-data=2")
+	}
+	cmdArgs = append(cmdArgs, localDatabase.Name, "--result-file="+path)
+	_, err = exec.Command(executable, cmdArgs...).Output()
+	if err != nil {
+		SleepyErrorLn("%v", cmdArgs)
+		os.RemoveAll(dumpPath)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Runs apart from the websocket loop, since verification alone can take minutes
+func RequestDatabaseBackup(handler *Handler, message WebsocketRequestDatabaseBackupMessage) {
+	taskProgressMessage := WebsocketTaskProgressMessage{
+		Type:   WebsocketMessageTypeTaskProgress,
+		ID:     message.Task,
+		Status: TaskStatusRunning,
+	}
+	var path string
+	var err error
+	if message.Data {
+		path, err = CreateBackup(handler, message.Database)
+	} else {
+		path, err = CreateBackup(handler, message.Database, "--no-data")
+	}
+	if err != nil {
+		SleepyWarnLn("Failed to create a database backup! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Backup of "+message.Database, err)
+		taskProgressMessage.Status = TaskStatusFailed
+		SendWebsocketMessage(handler, taskProgressMessage)
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if message.Verify {
+		taskProgressMessage.Progress = 50
+		SendWebsocketMessage(handler, taskProgressMessage)
+		verification := VerifyBackup(handler, message.Database, path)
+		taskProgressMessage.Verification = &verification
+	}
+
+	uploadFileData := UploadFileBackupDatabaseData{
+		Type:         UploadFileDataBackupDatabase,
+		Database:     message.Database,
+		Task:         message.Task,
+		Verification: taskProgressMessage.Verification,
+	}
+	destination, err := GetBackupDestination(handler, message.Destination)
+	if err == nil {
+		// The API keeps receiving the plain name it always did, other destinations collect many backups side by side
+		if _, ok := destination.(APIBackupDestination); !ok {
+			timestampedPath := filepath.Join(filepath.Dir(path), GetTimestampedBackupName(path, time.Now()))
+			err = os.Rename(path, timestampedPath)
+			if err == nil {
+				path = timestampedPath
+			}
+		}
+	}
+	if err == nil {
+		err = destination.Store(handler, path, uploadFileData)
+	}
+	if err != nil {
+		SleepyWarnLn("Failed to upload database backup! (%s)", err.Error())
+		NotifyTaskFailure(handler, "Backup of "+message.Database, err)
+		taskProgressMessage.Status = TaskStatusFailed
+		SendWebsocketMessage(handler, taskProgressMessage)
+		return
+	}
+	taskProgressMessage.Status = TaskStatusFinished
+	taskProgressMessage.Progress = 100
+	SendWebsocketMessage(handler, taskProgressMessage)
+}
+
+func GetDatabaseCredentials(handler *Handler, database string) (ConfigCredentialsDatabase, ConfigCredentialsDatabaseDatabase, bool) {
+	for _, credentials := range handler.Credentials.Databases {
+		for _, localDatabase := range credentials.Databases {
+			if localDatabase.ID == database {
+				return credentials, localDatabase, true
+			}
+		}
+	}
+
+	return ConfigCredentialsDatabase{}, ConfigCredentialsDatabaseDatabase{}, false
+}
+
+func GetMySQLDump() string {
+	return GetMySQLToolSystem(runtime.GOOS, "mysqldump")
+}
+
+func GetMySQLClient() string {
+	return GetMySQLToolSystem(runtime.GOOS, "mysql")
+}
+
+func GetMySQLToolSystem(system string, name string) string {
+	err := exec.Command(name).Run()
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		err := exec.Command(fmt.Sprintf("tools/%s/%s", system, name)).Run()
+		if err != nil && strings.Contains(err.Error(), "not found") {
+			return ""
+		}
+
+		return fmt.Sprintf("tools/%s/%s", system, name)
+	}
+
+	return name
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	NotificationChannelWebhook string = "webhook"
+	NotificationChannelDiscord string = "discord"
+	NotificationChannelSlack   string = "slack"
+	NotificationChannelSMTP    string = "smtp"
+)
+
+// Covers the whole conversation with the mail server, so a stalled one can't pile up goroutines
+const NotificationMailTimeout = time.Second * 30
+
+const (
+	NotificationEventAlert string = "alert"
+	NotificationEventTask  string = "task"
+)
+
+type Notification struct {
+	Event     string                 `json:"event"`
+	Host      string                 `json:"host"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Severity  string                 `json:"severity"`
+	Timestamp int64                  `json:"timestamp"`
+	Alert     *WebsocketAlertMessage `json:"alert"`
+}
+
+func NotifyAlert(handler *Handler, message WebsocketAlertMessage) {
+	var channels []string
+	for _, rule := range handler.Config.Alerts {
+		if rule.ID == message.Rule {
+			channels = rule.Notify
+		}
+	}
+	if len(channels) == 0 {
+		return
+	}
+
+	target := ""
+	if message.Target != "" {
+		target = " on " + message.Target
+	}
+	notification := NewNotification(NotificationEventAlert, message.Severity)
+	notification.Alert = &message
+	if message.State == AlertStateFiring {
+		notification.Title = fmt.Sprintf("[%s] %s is firing%s", notification.Host, message.Rule, target)
+		notification.Message = fmt.Sprintf("%s is %v, which is %s %v.", message.Metric, message.Value, message.Operator, message.Threshold)
+	} else {
+		notification.Title = fmt.Sprintf("[%s] %s resolved%s", notification.Host, message.Rule, target)
+		notification.Message = fmt.Sprintf("%s is no longer %s %v.", message.Metric, message.Operator, message.Threshold)
+	}
+	go SendNotification(handler, channels, notification)
+}
+
+func NotifyTaskFailure(handler *Handler, task string, err error) {
+	channels := handler.Config.Notifications.Tasks
+	if len(channels) == 0 {
+		return
+	}
+
+	notification := NewNotification(NotificationEventTask, "critical")
+	notification.Title = fmt.Sprintf("[%s] %s failed", notification.Host, task)
+	notification.Message = err.Error()
+	go SendNotification(handler, channels, notification)
+}
+
+func NewNotification(event string, severity string) Notification {
+	hostname, _ := os.Hostname()
+	return Notification{
+		Event:     event,
+		Host:      hostname,
+		Severity:  severity,
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
+
+func SendNotification(handler *Handler, channels []string, notification Notification) {
+	for _, id := range channels {
+		channel, ok := GetNotificationChannel(handler, id)
+		if !ok {
+			SleepyWarnLn("Failed to find notification channel! (id: %s)", id)
+			continue
+		}
+		credentials := GetNotificationCredentials(handler, id)
+		var err error
+		switch channel.Type {
+		case NotificationChannelWebhook:
+			err = PostNotificationJSON(credentials.URL, notification)
+		case NotificationChannelDiscord:
+			err = PostNotificationJSON(credentials.URL, map[string]string{"content": "**" + notification.Title + "**\n" + notification.Message})
+		case NotificationChannelSlack:
+			err = PostNotificationJSON(credentials.URL, map[string]string{"text": "*" + notification.Title + "*\n" + notification.Message})
+		case NotificationChannelSMTP:
+			err = SendNotificationMail(channel, credentials.Password, notification)
+		default:
+			err = fmt.Errorf("unknown channel type: %s", channel.Type)
+		}
+		if err != nil {
+			SleepyWarnLn("Failed to send notification! (channel: %s, %s)", id, err.Error())
+		}
+	}
+}
+
+func GetNotificationChannel(handler *Handler, id string) (ConfigNotificationChannel, bool) {
+	for _, channel := range handler.Config.Notifications.Channels {
+		if channel.ID == id {
+			return channel, true
+		}
+	}
+
+	return ConfigNotificationChannel{}, false
+}
+
+func GetNotificationCredentials(handler *Handler, id string) ConfigCredentialsNotificationChannel {
+	for _, credentials := range handler.Credentials.Notifications {
+		if credentials.ID == id {
+			return credentials
+		}
+	}
+
+	return ConfigCredentialsNotificationChannel{ID: id}
+}
+
+func PostNotificationJSON(url string, body any) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: time.Second * 10}
+	res, err := client.Post(url, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("bad status: %s (%s)", res.Status, strings.TrimSpace(string(resBody)))
+	}
+
+	return nil
+}
+
+// Upgrades to STARTTLS when the server offers it, smtp.PlainAuth then refuses to authenticate without it.
+func SendNotificationMail(channel ConfigNotificationChannel, password string, notification Notification) error {
+	if len(channel.To) == 0 {
+		return errors.New("no recipients specified")
+	}
+	port := channel.Port
+	if port == "" {
+		port = "587"
+	}
+	var auth smtp.Auth
+	if channel.Username != "" {
+		auth = smtp.PlainAuth("", channel.Username, password, channel.Host)
+	}
+
+	// Header values can't contain line breaks, the title comes from config and hostnames though
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(notification.Title)
+	var mail strings.Builder
+	mail.WriteString("From: " + channel.From + "\r\n")
+	mail.WriteString("To: " + strings.Join(channel.To, ", ") + "\r\n")
+	mail.WriteString("Subject: " + subject + "\r\n")
+	mail.WriteString("Date: " + time.UnixMilli(notification.Timestamp).Format(time.RFC1123Z) + "\r\n")
+	mail.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	mail.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n") + "\r\n")
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(channel.Host, port), NotificationMailTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(NotificationMailTimeout))
+	client, err := smtp.NewClient(conn, channel.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: channel.Host})
+		if err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(channel.From)
+	if err != nil {
+		return err
+	}
+	for _, to := range channel.To {
+		err = client.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write([]byte(mail.String()))
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
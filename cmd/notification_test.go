@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendNotificationPayloads(t *testing.T) {
+	payloads := make(map[string]map[string]any)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		payloads[r.URL.Path] = payload
+	}))
+	defer server.Close()
+
+	var handler Handler
+	handler.Config.Notifications.Channels = []ConfigNotificationChannel{
+		{ID: "hook", Type: NotificationChannelWebhook},
+		{ID: "discord", Type: NotificationChannelDiscord},
+		{ID: "slack", Type: NotificationChannelSlack},
+	}
+	handler.Credentials.Notifications = []ConfigCredentialsNotificationChannel{
+		{ID: "hook", URL: server.URL + "/hook"},
+		{ID: "discord", URL: server.URL + "/discord"},
+		{ID: "slack", URL: server.URL + "/slack"},
+	}
+	notification := NewNotification(NotificationEventTask, "critical")
+	notification.Title = "Backup failed"
+	notification.Message = "disk full"
+	SendNotification(&handler, []string{"hook", "discord", "slack"}, notification)
+
+	if payloads["/hook"]["title"] != "Backup failed" || payloads["/hook"]["event"] != NotificationEventTask {
+		t.Errorf("unexpected webhook payload: %v", payloads["/hook"])
+	}
+	if payloads["/discord"]["content"] != "**Backup failed**\ndisk full" {
+		t.Errorf("unexpected discord payload: %v", payloads["/discord"])
+	}
+	if payloads["/slack"]["text"] != "*Backup failed*\ndisk full" {
+		t.Errorf("unexpected slack payload: %v", payloads["/slack"])
+	}
+}
+
+func TestPostNotificationJSONBadStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "unknown webhook")
+	}))
+	defer server.Close()
+
+	err := PostNotificationJSON(server.URL, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "unknown webhook") {
+		t.Errorf("expected a bad status error, got %v", err)
+	}
+}
+
+// Speaks just enough SMTP to accept one mail, without STARTTLS so PlainAuth only works on localhost
+func serveTestSMTP(t *testing.T, listener net.Listener, commands chan<- string, data chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+
+	reply("220 localhost ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		commands <- line
+		switch {
+		case strings.HasPrefix(line, "EHLO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(line, "AUTH"):
+			reply("235 authenticated")
+		case strings.HasPrefix(line, "MAIL"), strings.HasPrefix(line, "RCPT"):
+			reply("250 ok")
+		case line == "DATA":
+			reply("354 go ahead")
+			var body strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				body.WriteString(line)
+			}
+			data <- body.String()
+			reply("250 queued")
+		case line == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			t.Errorf("unexpected smtp command: %s", line)
+			reply("502 unknown")
+		}
+	}
+}
+
+func TestSendNotificationMail(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	commands := make(chan string, 16)
+	data := make(chan string, 1)
+	go serveTestSMTP(t, listener, commands, data)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	channel := ConfigNotificationChannel{
+		ID:       "mail",
+		Type:     NotificationChannelSMTP,
+		Host:     host,
+		Port:     port,
+		Username: "alerts",
+		From:     "alerts@example.com",
+		To:       []string{"admin@example.com", "ops@example.com"},
+	}
+	notification := NewNotification(NotificationEventTask, "critical")
+	notification.Title = "Backup\r\nBcc: evil@example.com"
+	notification.Message = "disk full\nretrying later"
+	err = SendNotificationMail(channel, "secret", notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	close(commands)
+	received := []string{}
+	for command := range commands {
+		received = append(received, command)
+	}
+	expected := []string{"AUTH PLAIN AGFsZXJ0cwBzZWNyZXQ=", "MAIL FROM:<alerts@example.com>", "RCPT TO:<admin@example.com>", "RCPT TO:<ops@example.com>", "DATA", "QUIT"}
+	if len(received) != len(expected)+1 || strings.Join(received[1:], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected smtp commands: %q", received)
+	}
+	body := <-data
+	if !strings.Contains(body, "Subject: Backup  Bcc: evil@example.com\r\n") || !strings.Contains(body, "disk full\r\nretrying later\r\n") {
+		t.Errorf("unexpected mail:\n%s", body)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OutputTypeInfluxHTTP string = "influx-http"
+	OutputTypeInfluxUDP  string = "influx-udp"
+	OutputTypeStatsD     string = "statsd"
+)
+
+// Keeps UDP datagrams under a typical MTU
+const OutputMaxPacketSize = 1400
+
+type OutputPoint struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]float64
+}
+
+func RunOutputs(handler *Handler) {
+	for _, output := range handler.Config.Outputs {
+		go RunOutput(handler, output)
+	}
+}
+
+func RunOutput(handler *Handler, output ConfigOutput) {
+	interval := time.Duration(MathMin(int64(output.Interval), 1)) * time.Second
+	SleepyLogLn("Pushing stats to %s every %d s... (type: %s)", output.Address, interval/time.Second, output.Type)
+	token := ""
+	for _, credentials := range handler.Credentials.Outputs {
+		if credentials.ID == output.ID {
+			token = credentials.Token
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		stats := GetLatestStats(handler, interval)
+		points := GetOutputPoints(handler, stats)
+		var err error
+		switch output.Type {
+		case OutputTypeInfluxHTTP:
+			err = WriteInfluxHTTP(output.Address, token, points, time.UnixMilli(stats.Timestamp))
+		case OutputTypeInfluxUDP:
+			err = WriteUDPLines(output.Address, GetInfluxLines(points, time.UnixMilli(stats.Timestamp)))
+		case OutputTypeStatsD:
+			err = WriteUDPLines(output.Address, GetStatsDLines(output.Prefix, points))
+		default:
+			err = fmt.Errorf("unknown output type: %s", output.Type)
+		}
+		if err != nil {
+			SleepyWarnLn("Failed to push stats! (address: %s, %s)", output.Address, err.Error())
+		}
+	}
+}
+
+func GetOutputPoints(handler *Handler, stats WebsocketRequestStatsReplyMessage) []OutputPoint {
+	hostname, _ := os.Hostname()
+	tags := func(pairs ...string) map[string]string {
+		result := map[string]string{"host": hostname}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			result[pairs[i]] = pairs[i+1]
+		}
+		return result
+	}
+
+	cpu := stats.CPU
+	memory := stats.Memory.Details
+	points := []OutputPoint{
+		{"cpu", tags(), map[string]float64{
+			"user":             MetricsFloat32(cpu.User),
+			"system":           MetricsFloat32(cpu.System),
+			"nice":             MetricsFloat32(cpu.Nice),
+			"iowait":           MetricsFloat32(cpu.Iowait),
+			"irq":              MetricsFloat32(cpu.Irq),
+			"softirq":          MetricsFloat32(cpu.Softirq),
+			"steal":            MetricsFloat32(cpu.Steal),
+			"load1":            MetricsFloat32(cpu.Load.Load1),
+			"load5":            MetricsFloat32(cpu.Load.Load5),
+			"load15":           MetricsFloat32(cpu.Load.Load15),
+			"context_switches": float64(cpu.ContextSwitches),
+			"interrupts":       float64(cpu.Interrupts),
+		}},
+		{"memory", tags(), map[string]float64{
+			"total":      float64(memory.Total),
+			"used":       float64(memory.Used),
+			"free":       float64(memory.Free),
+			"available":  float64(memory.Available),
+			"buffers":    float64(memory.Buffers),
+			"cached":     float64(memory.Cached),
+			"swap_total": float64(memory.SwapTotal),
+			"swap_used":  float64(memory.SwapUsed),
+		}},
+	}
+	for _, disk := range stats.Disks {
+		points = append(points, OutputPoint{"disk", tags("disk", disk.Parent), map[string]float64{
+			"read":          float64(disk.Read),
+			"write":         float64(disk.Write),
+			"read_latency":  float64(disk.ReadLatency),
+			"write_latency": float64(disk.WriteLatency),
+		}})
+	}
+	for _, networkInterface := range stats.Network.Interfaces {
+		points = append(points, OutputPoint{"net", tags("interface", networkInterface.Name), map[string]float64{
+			"rx":         float64(networkInterface.RX),
+			"tx":         float64(networkInterface.TX),
+			"rx_packets": float64(networkInterface.RXPackets),
+			"tx_packets": float64(networkInterface.TXPackets),
+			"rx_errors":  float64(networkInterface.RXErrors),
+			"tx_errors":  float64(networkInterface.TXErrors),
+		}})
+	}
+	containerLabels := GetMetricsContainerLabels(handler)
+	for _, usage := range stats.Containers {
+		labels, ok := containerLabels[usage.Parent]
+		if !ok {
+			continue
+		}
+		points = append(points, OutputPoint{"container", tags(labels...), map[string]float64{
+			"cpu":    MetricsFloat32(usage.CPU),
+			"memory": float64(usage.Memory),
+			"rx":     float64(usage.RX),
+			"tx":     float64(usage.TX),
+			"read":   float64(usage.Read),
+			"write":  float64(usage.Write),
+		}})
+	}
+	for _, sensor := range stats.Sensors {
+		points = append(points, OutputPoint{"sensor", tags("chip", sensor.Chip, "label", sensor.Label, "type", strings.ToLower(sensor.Type)), map[string]float64{
+			"value": sensor.Value,
+		}})
+	}
+
+	return points
+}
+
+func GetSortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// https://docs.influxdata.com/influxdb/v2/reference/syntax/line-protocol/
+func GetInfluxLines(points []OutputPoint, timestamp time.Time) []string {
+	escapeKey := strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	lines := []string{}
+	for _, point := range points {
+		var line strings.Builder
+		line.WriteString(strings.NewReplacer(",", `\,`, " ", `\ `).Replace(point.Measurement))
+		for _, key := range GetSortedKeys(point.Tags) {
+			// Empty tag values aren't allowed
+			if point.Tags[key] == "" {
+				continue
+			}
+			line.WriteString("," + escapeKey.Replace(key) + "=" + escapeKey.Replace(point.Tags[key]))
+		}
+		fields := []string{}
+		for _, key := range GetSortedKeys(point.Fields) {
+			fields = append(fields, escapeKey.Replace(key)+"="+strconv.FormatFloat(point.Fields[key], 'g', -1, 64))
+		}
+		line.WriteString(" " + strings.Join(fields, ",") + " " + strconv.FormatInt(timestamp.UnixNano(), 10))
+		lines = append(lines, line.String())
+	}
+
+	return lines
+}
+
+// StatsD has no tags, so they become part of the bucket name, like sleepy.host.net.eth0.rx
+func GetStatsDLines(prefix string, points []OutputPoint) []string {
+	escape := strings.NewReplacer(".", "_", ":", "_", "|", "_", " ", "_", "@", "_")
+	lines := []string{}
+	for _, point := range points {
+		path := []string{escape.Replace(point.Tags["host"]), point.Measurement}
+		for _, key := range GetSortedKeys(point.Tags) {
+			if key != "host" && point.Tags[key] != "" {
+				path = append(path, escape.Replace(point.Tags[key]))
+			}
+		}
+		if prefix != "" {
+			path = append([]string{prefix}, path...)
+		}
+		for _, key := range GetSortedKeys(point.Fields) {
+			lines = append(lines, fmt.Sprintf("%s.%s:%s|g", strings.Join(path, "."), key, strconv.FormatFloat(point.Fields[key], 'f', -1, 64)))
+		}
+	}
+
+	return lines
+}
+
+// Address is the full write URL, for example http://influx:8086/api/v2/write?org=o&bucket=b
+func WriteInfluxHTTP(address string, token string, points []OutputPoint, timestamp time.Time) error {
+	body := strings.Join(GetInfluxLines(points, timestamp), "\n")
+	req, err := http.NewRequest("POST", address, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	client := http.Client{Timeout: time.Second * 10}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("bad status: %s (%s)", res.Status, strings.TrimSpace(string(resBody)))
+	}
+
+	return nil
+}
+
+// Packs as many lines as fit into each datagram.
+func WriteUDPLines(address string, lines []string) error {
+	if address == "" {
+		return errors.New("no address specified")
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var packet bytes.Buffer
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > OutputMaxPacketSize {
+			if _, err := conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		_, err = conn.Write(packet.Bytes())
+	}
+
+	return err
+}
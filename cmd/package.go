@@ -0,0 +1,45 @@
+package main
+
+import (
+	"runtime"
+)
+
+const (
+	PackageManagerDpkg string = "dpkg"
+	PackageManagerRpm  string = "rpm"
+	PackageManagerApk  string = "apk"
+)
+
+type Package struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Architecture string `json:"architecture"`
+	Manager      string `json:"manager"`
+}
+
+type PackageUpdate struct {
+	Name      string `json:"name"`
+	Current   string `json:"current"`
+	Available string `json:"available"`
+	Security  bool   `json:"security"`
+	Manager   string `json:"manager"`
+}
+
+func GetInstalledPackages() []Package {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetInstalledPackagesSystem()
+	default:
+		return []Package{}
+	}
+}
+
+// Only uses metadata the package manager already has cached, it never refreshes it.
+func GetPackageUpdates() []PackageUpdate {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetPackageUpdatesSystem()
+	default:
+		return []PackageUpdate{}
+	}
+}
@@ -0,0 +1,293 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+const (
+	PackageDpkgStatusPathLinux   = "/var/lib/dpkg/status"
+	PackageApkInstalledPathLinux = "/lib/apk/db/installed"
+)
+
+// apk joins name and version with a dash, the version is the part starting with a digit and ending in -rN
+var PackageApkNameRegexLinux = regexp.MustCompile(`^(.+)-(\d[^-]*-r\d+)$`)
+
+var PackageApkUpgradableRegexLinux = regexp.MustCompile(`\[upgradable from: (\S+)\]`)
+
+func GetInstalledPackagesSystem() []Package {
+	if _, err := os.Stat(PackageDpkgStatusPathLinux); err == nil {
+		return GetDpkgPackagesLinux()
+	}
+	if _, err := os.Stat(PackageApkInstalledPathLinux); err == nil {
+		return GetApkPackagesLinux()
+	}
+	if _, err := exec.LookPath("rpm"); err == nil {
+		return GetRpmPackagesLinux()
+	}
+
+	return []Package{}
+}
+
+// https://man7.org/linux/man-pages/man5/deb-control.5.html
+func GetDpkgPackagesLinux() []Package {
+	packages := []Package{}
+	file, err := os.Open(PackageDpkgStatusPathLinux)
+	if err != nil {
+		SleepyWarnLn("Failed to get installed packages! (%s)", err.Error())
+		return packages
+	}
+	defer file.Close()
+
+	var current Package
+	installed := false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if installed && current.Name != "" {
+				packages = append(packages, current)
+			}
+			current = Package{}
+			installed = false
+			continue
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Package":
+			current.Name = value
+			current.Manager = PackageManagerDpkg
+		case "Version":
+			current.Version = value
+		case "Architecture":
+			current.Architecture = value
+		case "Status":
+			installed = strings.HasSuffix(value, " installed")
+		}
+	}
+	if installed && current.Name != "" {
+		packages = append(packages, current)
+	}
+
+	return packages
+}
+
+// https://wiki.alpinelinux.org/wiki/Apk_spec
+func GetApkPackagesLinux() []Package {
+	packages := []Package{}
+	file, err := os.Open(PackageApkInstalledPathLinux)
+	if err != nil {
+		SleepyWarnLn("Failed to get installed packages! (%s)", err.Error())
+		return packages
+	}
+	defer file.Close()
+
+	current := Package{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if current.Name != "" {
+				packages = append(packages, current)
+			}
+			current = Package{}
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		switch line[0] {
+		case 'P':
+			current.Name = line[2:]
+			current.Manager = PackageManagerApk
+		case 'V':
+			current.Version = line[2:]
+		case 'A':
+			current.Architecture = line[2:]
+		}
+	}
+	if current.Name != "" {
+		packages = append(packages, current)
+	}
+
+	return packages
+}
+
+func GetRpmPackagesLinux() []Package {
+	packages := []Package{}
+	rpmStdout, err := exec.Command("rpm", "-qa", "--queryformat", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{ARCH}\n`).Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get installed packages! (%s)", err.Error())
+		return packages
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(rpmStdout)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		packages = append(packages, Package{
+			Name:         fields[0],
+			Version:      fields[1],
+			Architecture: fields[2],
+			Manager:      PackageManagerRpm,
+		})
+	}
+
+	return packages
+}
+
+func GetPackageUpdatesSystem() []PackageUpdate {
+	var updates []PackageUpdate
+	var err error
+	switch {
+	case IsCommandAvailableLinux("apt"):
+		updates, err = GetAptUpdatesLinux()
+	case IsCommandAvailableLinux("apk"):
+		updates, err = GetApkUpdatesLinux()
+	case IsCommandAvailableLinux("dnf"):
+		updates, err = GetDnfUpdatesLinux("dnf")
+	case IsCommandAvailableLinux("yum"):
+		updates, err = GetDnfUpdatesLinux("yum")
+	default:
+		return []PackageUpdate{}
+	}
+	if err != nil {
+		SleepyWarnLn("Failed to get package updates! (%s)", err.Error())
+		return []PackageUpdate{}
+	}
+
+	return updates
+}
+
+func IsCommandAvailableLinux(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// Lines look like: "openssl/stable-security 3.0.15-1~deb12u1 amd64 [upgradable from: 3.0.14-1~deb12u2]"
+func GetAptUpdatesLinux() ([]PackageUpdate, error) {
+	aptStdout, err := exec.Command("apt", "list", "--upgradable").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	updates := []PackageUpdate{}
+	for _, line := range strings.Split(string(aptStdout), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.Contains(fields[0], "/") {
+			continue
+		}
+		name, suites, _ := strings.Cut(fields[0], "/")
+		updates = append(updates, PackageUpdate{
+			Name:      name,
+			Current:   strings.TrimSuffix(fields[5], "]"),
+			Available: fields[1],
+			Security:  strings.Contains(suites, "-security"),
+			Manager:   PackageManagerDpkg,
+		})
+	}
+
+	return updates, nil
+}
+
+// Lines look like: "musl-1.2.4-r3 x86_64 {musl} (MIT) [upgradable from: musl-1.2.4-r2]"
+func GetApkUpdatesLinux() ([]PackageUpdate, error) {
+	apkStdout, err := exec.Command("apk", "list", "--upgradable", "--no-network").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	updates := []PackageUpdate{}
+	for _, line := range strings.Split(string(apkStdout), "\n") {
+		fields := strings.Fields(line)
+		from := PackageApkUpgradableRegexLinux.FindStringSubmatch(line)
+		if len(fields) < 1 || from == nil {
+			continue
+		}
+		available := PackageApkNameRegexLinux.FindStringSubmatch(fields[0])
+		current := PackageApkNameRegexLinux.FindStringSubmatch(from[1])
+		if available == nil || current == nil {
+			continue
+		}
+		// Alpine publishes security fixes as regular updates, so they can't be told apart
+		updates = append(updates, PackageUpdate{
+			Name:      available[1],
+			Current:   current[2],
+			Available: available[2],
+			Manager:   PackageManagerApk,
+		})
+	}
+
+	return updates, nil
+}
+
+// check-update exits with 100 when there are updates, so that isn't an error
+func GetDnfUpdatesLinux(command string) ([]PackageUpdate, error) {
+	updateStdout, err := exec.Command(command, "-C", "-q", "check-update").Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 100) {
+		return nil, err
+	}
+
+	// Security advisories list the full NEVRA, so match them by name
+	security := make(map[string]bool)
+	if securityStdout, err := exec.Command(command, "-C", "-q", "updateinfo", "list", "--security").Output(); err == nil {
+		for _, line := range strings.Split(string(securityStdout), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 {
+				security[fields[len(fields)-1]] = true
+			}
+		}
+	}
+
+	current := make(map[string]string)
+	for _, installed := range GetRpmPackagesLinux() {
+		current[installed.Name+"."+installed.Architecture] = installed.Version
+	}
+
+	updates := []PackageUpdate{}
+	scanner := bufio.NewScanner(bytes.NewReader(updateStdout))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		// Everything after this line is packages being obsoleted
+		if fields[0] == "Obsoleting" {
+			break
+		}
+		nameArch := fields[0]
+		name := nameArch
+		if i := strings.LastIndex(nameArch, "."); i > 0 {
+			name = nameArch[:i]
+		}
+		version := fields[1]
+		// Versions may carry an epoch, which the NEVRA in advisories includes too
+		nevraVersion := version
+		if _, withoutEpoch, ok := strings.Cut(version, ":"); ok {
+			nevraVersion = withoutEpoch
+		}
+		arch := strings.TrimPrefix(nameArch, name+".")
+		updates = append(updates, PackageUpdate{
+			Name:      name,
+			Current:   current[nameArch],
+			Available: version,
+			Security:  security[name+"-"+nevraVersion+"."+arch] || security[name+"-"+version+"."+arch],
+			Manager:   PackageManagerRpm,
+		})
+	}
+
+	return updates, nil
+}
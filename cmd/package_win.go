@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package main
+
+func GetInstalledPackagesSystem() []Package {
+	return []Package{}
+}
+
+func GetPackageUpdatesSystem() []PackageUpdate {
+	return []PackageUpdate{}
+}
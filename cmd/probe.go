@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ProbeTypeHTTP string = "http"
+	ProbeTypeTCP  string = "tcp"
+	ProbeTypePing string = "ping"
+	ProbeTypeUDP  string = "udp"
+)
+
+const (
+	ProbeDefaultInterval = 60
+	ProbeDefaultTimeout  = 5000
+)
+
+// Only this much of an HTTP body is searched for the keyword
+const ProbeMaxBodySize = 1024 * 1024
+
+type DaemonProbeManager struct {
+	Mutex   *sync.Mutex
+	Server  []ConfigProbe
+	Results map[string]ProbeResult
+	Next    map[string]time.Time
+	Running map[string]bool
+}
+
+// Latency is in milliseconds, TLSExpiry is a unix timestamp of the earliest certificate expiry.
+type ProbeResult struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	Target    string  `json:"target"`
+	Success   bool    `json:"success"`
+	Latency   float64 `json:"latency"`
+	Status    *int    `json:"status"`
+	TLSExpiry *int64  `json:"tlsExpiry"`
+	Error     *string `json:"error"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+func InitProbeManager(handler *Handler) {
+	handler.ProbeManager = DaemonProbeManager{
+		Mutex:   &sync.Mutex{},
+		Server:  []ConfigProbe{},
+		Results: make(map[string]ProbeResult),
+		Next:    make(map[string]time.Time),
+		Running: make(map[string]bool),
+	}
+}
+
+// Probes from the config are merged with the ones the server sent, the config wins on the same ID.
+func GetProbeDefinitions(handler *Handler) []ConfigProbe {
+	handler.ProbeManager.Mutex.Lock()
+	defer handler.ProbeManager.Mutex.Unlock()
+
+	probes := append([]ConfigProbe{}, handler.Config.Probes...)
+	for _, serverProbe := range handler.ProbeManager.Server {
+		exists := false
+		for _, probe := range probes {
+			if probe.ID == serverProbe.ID {
+				exists = true
+			}
+		}
+		if !exists {
+			probes = append(probes, serverProbe)
+		}
+	}
+
+	return probes
+}
+
+func SetServerProbes(handler *Handler, probes []ConfigProbe) {
+	handler.ProbeManager.Mutex.Lock()
+	defer handler.ProbeManager.Mutex.Unlock()
+
+	handler.ProbeManager.Server = probes
+	// Drop results of probes that no longer exist
+	ids := make(map[string]bool)
+	for _, probe := range append(append([]ConfigProbe{}, handler.Config.Probes...), probes...) {
+		ids[probe.ID] = true
+	}
+	for id := range handler.ProbeManager.Results {
+		if !ids[id] {
+			delete(handler.ProbeManager.Results, id)
+			delete(handler.ProbeManager.Next, id)
+		}
+	}
+	SleepyLogLn("Updated server probes! (count: %d)", len(probes))
+}
+
+func GetProbeResults(handler *Handler) []ProbeResult {
+	handler.ProbeManager.Mutex.Lock()
+	defer handler.ProbeManager.Mutex.Unlock()
+
+	results := []ProbeResult{}
+	for _, result := range handler.ProbeManager.Results {
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func RunProbeScheduler(handler *Handler) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		for _, probe := range GetProbeDefinitions(handler) {
+			handler.ProbeManager.Mutex.Lock()
+			due := !now.Before(handler.ProbeManager.Next[probe.ID]) && !handler.ProbeManager.Running[probe.ID]
+			if due {
+				interval := probe.Interval
+				if interval == 0 {
+					interval = ProbeDefaultInterval
+				}
+				handler.ProbeManager.Next[probe.ID] = now.Add(time.Duration(interval) * time.Second)
+				handler.ProbeManager.Running[probe.ID] = true
+			}
+			handler.ProbeManager.Mutex.Unlock()
+			if !due {
+				continue
+			}
+
+			go func(probe ConfigProbe) {
+				result := RunProbe(probe)
+				handler.ProbeManager.Mutex.Lock()
+				handler.ProbeManager.Results[probe.ID] = result
+				handler.ProbeManager.Running[probe.ID] = false
+				handler.ProbeManager.Mutex.Unlock()
+			}(probe)
+		}
+	}
+}
+
+func RunProbe(probe ConfigProbe) ProbeResult {
+	timeout := time.Duration(probe.Timeout) * time.Millisecond
+	if timeout == 0 {
+		timeout = ProbeDefaultTimeout * time.Millisecond
+	}
+	result := ProbeResult{
+		ID:        probe.ID,
+		Type:      probe.Type,
+		Target:    probe.Target,
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	start := time.Now()
+	var latency time.Duration
+	var err error
+	switch probe.Type {
+	case ProbeTypeHTTP:
+		err = RunHTTPProbe(probe, timeout, &result)
+	case ProbeTypeTCP:
+		var conn net.Conn
+		conn, err = net.DialTimeout("tcp", probe.Target, timeout)
+		if err == nil {
+			conn.Close()
+		}
+	case ProbeTypeUDP:
+		err = RunUDPProbe(probe, timeout)
+	case ProbeTypePing:
+		latency, err = PingSystem(probe.Target, timeout)
+	default:
+		err = fmt.Errorf("unknown probe type: %s", probe.Type)
+	}
+	if latency == 0 {
+		latency = time.Since(start)
+	}
+	result.Latency = float64(latency) / float64(time.Millisecond)
+	if err != nil {
+		message := err.Error()
+		result.Error = &message
+		return result
+	}
+	result.Success = true
+
+	return result
+}
+
+func RunHTTPProbe(probe ConfigProbe, timeout time.Duration, result *ProbeResult) error {
+	// Every run gets its own transport, keeping connections alive would leak them and skip the handshake
+	client := http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: probe.Insecure},
+			DisableKeepAlives: true,
+		},
+	}
+	res, err := client.Get(probe.Target)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	result.Status = &res.StatusCode
+	if res.TLS != nil {
+		for _, certificate := range res.TLS.PeerCertificates {
+			expiry := certificate.NotAfter.Unix()
+			if result.TLSExpiry == nil || expiry < *result.TLSExpiry {
+				result.TLSExpiry = &expiry
+			}
+		}
+	}
+
+	if probe.Status != 0 && res.StatusCode != probe.Status {
+		return fmt.Errorf("unexpected status: %d", res.StatusCode)
+	}
+	if probe.Status == 0 && res.StatusCode >= 400 {
+		return fmt.Errorf("bad status: %d", res.StatusCode)
+	}
+	if probe.Keyword != "" {
+		body, err := io.ReadAll(io.LimitReader(res.Body, ProbeMaxBodySize))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), probe.Keyword) {
+			return fmt.Errorf("keyword not found: %s", probe.Keyword)
+		}
+	}
+
+	return nil
+}
+
+// Succeeds on any reply, a closed port usually fails the read with a connection refused.
+func RunUDPProbe(probe ConfigProbe, timeout time.Duration) error {
+	conn, err := net.DialTimeout("udp", probe.Target, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	_, err = conn.Write([]byte(probe.Payload))
+	if err != nil {
+		return err
+	}
+	buffer := make([]byte, 1)
+	_, err = conn.Read(buffer)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return errors.New("no reply")
+		}
+		return err
+	}
+
+	return nil
+}
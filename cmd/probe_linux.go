@@ -0,0 +1,35 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var ProbePingTimeRegexLinux = regexp.MustCompile(`time[=<]([\d.]+) ?ms`)
+
+// Uses the system ping, since ICMP sockets need privileges the daemon might not have
+func PingSystem(host string, timeout time.Duration) (time.Duration, error) {
+	seconds := int(MathMin(int64(timeout/time.Second), 1))
+	pingStdout, err := exec.Command("ping", "-n", "-c", "1", "-W", fmt.Sprint(seconds), host).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return 0, errors.New("no reply")
+		}
+		return 0, err
+	}
+	match := ProbePingTimeRegexLinux.FindSubmatch(pingStdout)
+	if match == nil {
+		return 0, errors.New("failed to parse ping output")
+	}
+	ms, _ := strconv.ParseFloat(string(match[1]), 64)
+
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// The output is localized, but the time is always written as time=Nms or time<1ms
+var ProbePingTimeRegexWindows = regexp.MustCompile(`[=<](\d+) ?ms`)
+
+func PingSystem(host string, timeout time.Duration) (time.Duration, error) {
+	pingStdout, err := exec.Command("ping", "-n", "1", "-w", fmt.Sprint(timeout.Milliseconds()), host).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return 0, errors.New("no reply")
+		}
+		return 0, err
+	}
+	match := ProbePingTimeRegexWindows.FindSubmatch(pingStdout)
+	if match == nil {
+		return 0, errors.New("failed to parse ping output")
+	}
+	ms, _ := strconv.ParseFloat(string(match[1]), 64)
+
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
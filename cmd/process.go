@@ -1,18 +1,56 @@
-package main
-
-import "runtime"
-
-type Process struct {
-	Name      string `json:"name"`
-	Instances uint16 `json:"instances"`
-	Memory    uint64 `json:"memory"`
-}
-
-func GetProcesses() []Process {
-	switch runtime.GOOS {
-	case "windows":
-		return GetProcessesSystem()
-	default:
-		return []Process{}
-	}
-}
+package main
+
+import "runtime"
+
+type Process struct {
+	Name      string `json:"name"`
+	Instances uint16 `json:"instances"`
+	Memory    uint64 `json:"memory"`
+}
+
+type ProcessDetails struct {
+	PID       int     `json:"pid"`
+	PPID      int     `json:"ppid"`
+	User      string  `json:"user"`
+	Name      string  `json:"name"`
+	Command   string  `json:"command"`
+	CPU       float32 `json:"cpu"`
+	Memory    uint64  `json:"memory"`
+	Threads   uint32  `json:"threads"`
+	State     string  `json:"state"`
+	Container *string `json:"container"`
+}
+
+type ProcessRaw struct {
+	StartTime uint64
+	CPUTime   uint64
+}
+
+func GetProcesses(handler *Handler) ([]Process, []ProcessDetails) {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetProcessesSystem(handler)
+	default:
+		return []Process{}, []ProcessDetails{}
+	}
+}
+
+// Groups processes by name, the same way the Windows collector reports them.
+func GroupProcesses(details []ProcessDetails) []Process {
+	processes := []Process{}
+	indexes := make(map[string]int)
+	for _, detail := range details {
+		index, ok := indexes[detail.Name]
+		if !ok {
+			processes = append(processes, Process{
+				Name: detail.Name,
+			})
+			index = len(processes) - 1
+			indexes[detail.Name] = index
+		}
+		processes[index].Instances++
+		processes[index].Memory += detail.Memory
+	}
+
+	return processes
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	ProcessActionSignal string = "SIGNAL"
+	ProcessActionRenice string = "RENICE"
+	ProcessActionIonice string = "IONICE"
+)
+
+const (
+	ProcessIoniceClassRealtime   string = "REALTIME"
+	ProcessIoniceClassBestEffort string = "BEST_EFFORT"
+	ProcessIoniceClassIdle       string = "IDLE"
+)
+
+var ProcessSignals = map[string]int{
+	"HUP":  1,
+	"INT":  2,
+	"QUIT": 3,
+	"KILL": 9,
+	"USR1": 10,
+	"USR2": 12,
+	"TERM": 15,
+	"CONT": 18,
+	"STOP": 19,
+}
+
+func RequestProcessAction(handler *Handler, message WebsocketRequestProcessActionMessage) {
+	taskProgressMessage := WebsocketTaskProgressMessage{
+		Type:   WebsocketMessageTypeTaskProgress,
+		ID:     message.Task,
+		Status: TaskStatusFinished,
+	}
+	err := ProcessActionOnProcess(handler, message)
+	if err != nil {
+		SleepyWarnLn("Failed to process action on process! (pid: %d, action: %s, %s)", message.PID, message.Action, err.Error())
+		taskProgressMessage.Status = TaskStatusFailed
+	} else {
+		SleepyLogLn("Processed action on process! (pid: %d, action: %s)", message.PID, message.Action)
+		taskProgressMessage.Progress = 100
+	}
+	if message.Task != "" {
+		SendWebsocketMessage(handler, taskProgressMessage)
+	}
+}
+
+func ProcessActionOnProcess(handler *Handler, message WebsocketRequestProcessActionMessage) error {
+	if message.PID <= 1 || message.PID == os.Getpid() {
+		return errors.New("process is protected")
+	}
+	name, user, err := GetProcessIdentity(message.PID)
+	if err != nil {
+		return err
+	}
+	if !IsProcessActionAllowed(handler, name, user) {
+		return fmt.Errorf("process isn't allowed by the config (name: %s, user: %s)", name, user)
+	}
+
+	switch message.Action {
+	case ProcessActionSignal:
+		signal, err := ParseProcessSignal(message.Signal)
+		if err != nil {
+			return err
+		}
+		return SignalProcessSystem(message.PID, signal)
+	case ProcessActionRenice:
+		if message.Priority < -20 || message.Priority > 19 {
+			return fmt.Errorf("priority out of range: %d", message.Priority)
+		}
+		return ReniceProcessSystem(message.PID, message.Priority)
+	case ProcessActionIonice:
+		if message.Priority < 0 || message.Priority > 7 {
+			return fmt.Errorf("priority out of range: %d", message.Priority)
+		}
+		return IoniceProcessSystem(message.PID, message.Class, message.Priority)
+	default:
+		return fmt.Errorf("unknown action: %s", message.Action)
+	}
+}
+
+func ParseProcessSignal(raw string) (int, error) {
+	name := strings.TrimPrefix(strings.ToUpper(raw), "SIG")
+	if signal, ok := ProcessSignals[name]; ok {
+		return signal, nil
+	}
+	signal, err := strconv.Atoi(raw)
+	if err != nil || signal < 1 || signal > 64 {
+		return 0, fmt.Errorf("unknown signal: %s", raw)
+	}
+
+	return signal, nil
+}
+
+// Owners of system processes, an empty user is one the daemon couldn't read
+var ProcessActionProtectedUsers = []string{"", "root", "0", `NT AUTHORITY\SYSTEM`, `NT AUTHORITY\LOCAL SERVICE`, `NT AUTHORITY\NETWORK SERVICE`}
+
+// Deny rules always win. Without allow rules everything but system processes is allowed,
+// those have to be allowed explicitly.
+func IsProcessActionAllowed(handler *Handler, name string, user string) bool {
+	config := handler.Config.Processes
+	if MatchesAnyPattern(config.DenyNames, name) || MatchesAnyPattern(config.DenyUsers, user) {
+		return false
+	}
+	if len(config.AllowNames) == 0 && len(config.AllowUsers) == 0 {
+		for _, protected := range ProcessActionProtectedUsers {
+			if strings.EqualFold(user, protected) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return MatchesAnyPattern(config.AllowNames, name) || MatchesAnyPattern(config.AllowUsers, user)
+}
+
+func MatchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, value); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetProcessIdentity(pid int) (string, string, error) {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetProcessIdentitySystem(pid)
+	default:
+		return "", "", errors.New("unsupported platform")
+	}
+}
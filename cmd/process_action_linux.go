@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// https://man7.org/linux/man-pages/man2/ioprio_set.2.html
+const (
+	ProcessIoprioWhoProcessLinux = 1
+	ProcessIoprioClassShiftLinux = 13
+)
+
+var ProcessIoniceClassesLinux = map[string]int{
+	ProcessIoniceClassRealtime:   1,
+	ProcessIoniceClassBestEffort: 2,
+	ProcessIoniceClassIdle:       3,
+}
+
+func GetProcessIdentitySystem(pid int) (string, string, error) {
+	procPath := filepath.Join("/proc", fmt.Sprint(pid))
+	stat, err := os.ReadFile(filepath.Join(procPath, "stat"))
+	if err != nil {
+		return "", "", fmt.Errorf("process not found: %d", pid)
+	}
+	nameStart := bytes.IndexByte(stat, '(')
+	nameEnd := bytes.LastIndexByte(stat, ')')
+	if nameStart < 0 || nameEnd < nameStart {
+		return "", "", fmt.Errorf("failed to parse process: %d", pid)
+	}
+
+	return string(stat[nameStart+1 : nameEnd]), GetProcessUserLinux(procPath, make(map[string]string)), nil
+}
+
+func SignalProcessSystem(pid int, signal int) error {
+	return syscall.Kill(pid, syscall.Signal(signal))
+}
+
+func ReniceProcessSystem(pid int, priority int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, pid, priority)
+}
+
+func IoniceProcessSystem(pid int, class string, priority int) error {
+	classValue, ok := ProcessIoniceClassesLinux[class]
+	if !ok {
+		return fmt.Errorf("unknown ionice class: %s", class)
+	}
+	// The idle class has no priority levels
+	if class == ProcessIoniceClassIdle {
+		priority = 0
+	}
+	ioprio := classValue<<ProcessIoprioClassShiftLinux | priority
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ProcessIoprioWhoProcessLinux, uintptr(pid), uintptr(ioprio))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+type ProcessIdentityWindowsRaw struct {
+	Name     string
+	UserName *string
+}
+
+func GetProcessIdentitySystem(pid int) (string, string, error) {
+	processStdout, err := exec.Command("Powershell", "-Command", fmt.Sprintf("Get-Process -Id %d -IncludeUserName | Select-Object Name,UserName | ConvertTo-Json", pid)).Output()
+	if err != nil {
+		return "", "", fmt.Errorf("process not found: %d", pid)
+	}
+	var processRaw ProcessIdentityWindowsRaw
+	err = json.Unmarshal(processStdout, &processRaw)
+	if err != nil {
+		return "", "", err
+	}
+	user := ""
+	if processRaw.UserName != nil {
+		user = *processRaw.UserName
+	}
+
+	return processRaw.Name + ".exe", user, nil
+}
+
+// Windows has no signals, so anything that would end the process terminates it
+func SignalProcessSystem(pid int, signal int) error {
+	if signal != ProcessSignals["KILL"] && signal != ProcessSignals["TERM"] && signal != ProcessSignals["INT"] {
+		return errors.New("only KILL, TERM and INT are supported on Windows")
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return process.Kill()
+}
+
+func ReniceProcessSystem(pid int, priority int) error {
+	return errors.New("renice isn't supported on Windows")
+}
+
+func IoniceProcessSystem(pid int, class string, priority int) error {
+	return errors.New("ionice isn't supported on Windows")
+}
@@ -1,8 +1,162 @@
-//go:build linux
-// +build linux
-
-package main
-
-func GetProcessesSystem() []Process {
-	return []Process{}
-}
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// USER_HZ, which /proc reports CPU times in, is 100 on every architecture we run on
+const ProcessClockTicksLinux = 100
+
+var ProcessContainerIDRegex = regexp.MustCompile(`[0-9a-f]{64}`)
+
+func GetProcessesSystem(handler *Handler) ([]Process, []ProcessDetails) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		SleepyErrorLn("Failed to get process list! (%s)", err.Error())
+		return []Process{}, []ProcessDetails{}
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(handler.LastSnapshot.ProcessesTimestamp).Seconds()
+	pageSize := uint64(os.Getpagesize())
+	users := make(map[string]string)
+	rawProcesses := make(map[int]ProcessRaw)
+	details := []ProcessDetails{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		procPath := filepath.Join("/proc", entry.Name())
+
+		// The process name can contain spaces and parentheses, so split after the last one
+		// https://man7.org/linux/man-pages/man5/proc.5.html
+		stat, err := os.ReadFile(filepath.Join(procPath, "stat"))
+		if err != nil {
+			continue
+		}
+		nameStart := bytes.IndexByte(stat, '(')
+		nameEnd := bytes.LastIndexByte(stat, ')')
+		if nameStart < 0 || nameEnd < nameStart {
+			continue
+		}
+		name := string(stat[nameStart+1 : nameEnd])
+		fields := strings.Fields(string(stat[nameEnd+1:]))
+		if len(fields) < 22 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		// Skip kernel threads, which are children of kthreadd
+		if pid == 2 || ppid == 2 {
+			continue
+		}
+		utime, _ := strconv.ParseUint(fields[11], 10, 64)
+		stime, _ := strconv.ParseUint(fields[12], 10, 64)
+		threads, _ := strconv.ParseUint(fields[17], 10, 32)
+		startTime, _ := strconv.ParseUint(fields[19], 10, 64)
+		rss, _ := strconv.ParseUint(fields[21], 10, 64)
+
+		raw := ProcessRaw{
+			StartTime: startTime,
+			CPUTime:   utime + stime,
+		}
+		rawProcesses[pid] = raw
+		detail := ProcessDetails{
+			PID:     pid,
+			PPID:    ppid,
+			Name:    name,
+			Command: GetProcessCommandLinux(procPath, name),
+			Memory:  rss * pageSize,
+			Threads: uint32(threads),
+			State:   fields[0],
+		}
+
+		// Compare with the last snapshot of the same process, the PID could've been reused
+		last, ok := handler.LastSnapshot.RawProcesses[pid]
+		if ok && last.StartTime == raw.StartTime && elapsed > 0 {
+			detail.CPU = (float32(MathDeltaUint(raw.CPUTime, last.CPUTime)) / ProcessClockTicksLinux / float32(elapsed)) * 100
+		}
+		detail.User = GetProcessUserLinux(procPath, users)
+		detail.Container = GetProcessContainerLinux(handler, procPath)
+
+		details = append(details, detail)
+	}
+	handler.LastSnapshot.RawProcesses = rawProcesses
+	handler.LastSnapshot.ProcessesTimestamp = now
+
+	return GroupProcesses(details), details
+}
+
+func GetProcessCommandLinux(procPath string, name string) string {
+	cmdline, err := os.ReadFile(filepath.Join(procPath, "cmdline"))
+	if err != nil || len(cmdline) == 0 {
+		return "[" + name + "]"
+	}
+
+	return strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+}
+
+func GetProcessUserLinux(procPath string, users map[string]string) string {
+	file, err := os.Open(filepath.Join(procPath, "status"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return ""
+		}
+		uid := fields[1]
+		if name, ok := users[uid]; ok {
+			return name
+		}
+		name := uid
+		if u, err := user.LookupId(uid); err == nil {
+			name = u.Username
+		}
+		users[uid] = name
+
+		return name
+	}
+
+	return ""
+}
+
+// Docker puts containers in cgroups named after the full container ID, for both cgroup v1 and v2.
+func GetProcessContainerLinux(handler *Handler, procPath string) *string {
+	cgroup, err := os.ReadFile(filepath.Join(procPath, "cgroup"))
+	if err != nil {
+		return nil
+	}
+	rawID := ProcessContainerIDRegex.Find(cgroup)
+	if rawID == nil {
+		return nil
+	}
+	containers, _ := GetCachedContainers(handler)
+	for _, container := range containers {
+		if container.RawID != "" && strings.HasPrefix(string(rawID), container.RawID) {
+			id := container.ID
+			return &id
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import "runtime"
+
+// Size is in bytes, Devices is how many members the array should have and Active how many it has.
+type RaidArray struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Level    string       `json:"level"`
+	State    string       `json:"state"`
+	ReadOnly bool         `json:"readOnly"`
+	Degraded bool         `json:"degraded"`
+	Devices  uint32       `json:"devices"`
+	Active   uint32       `json:"active"`
+	Size     uint64       `json:"size"`
+	Sync     *RaidSync    `json:"sync"`
+	Children []RaidMember `json:"children"`
+}
+
+// Action is resync, recovery, reshape or check, Speed is in bytes per second.
+type RaidSync struct {
+	Action   string  `json:"action"`
+	Progress float32 `json:"progress"`
+	Finish   *string `json:"finish"`
+	Speed    uint64  `json:"speed"`
+}
+
+type RaidMember struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+const (
+	RaidMemberStateActive      = "active"
+	RaidMemberStateFaulty      = "faulty"
+	RaidMemberStateSpare       = "spare"
+	RaidMemberStateWriteMostly = "write-mostly"
+	RaidMemberStateReplacement = "replacement"
+)
+
+func GetRaidArrays(disks []Disk) []RaidArray {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetRaidArraysSystem(disks)
+	default:
+		return []RaidArray{}
+	}
+}
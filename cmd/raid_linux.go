@@ -0,0 +1,120 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var RaidMemberRegexLinux = regexp.MustCompile(`^(.+)\[\d+\](?:\(([A-Z])\))?$`)
+var RaidDevicesRegexLinux = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+var RaidSyncRegexLinux = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*([\d.]+)%`)
+var RaidFinishRegexLinux = regexp.MustCompile(`finish=(\S+)`)
+var RaidSpeedRegexLinux = regexp.MustCompile(`speed=(\d+)K/sec`)
+
+var RaidMemberStatesLinux = map[string]string{
+	"":  RaidMemberStateActive,
+	"F": RaidMemberStateFaulty,
+	"S": RaidMemberStateSpare,
+	"W": RaidMemberStateWriteMostly,
+	"R": RaidMemberStateReplacement,
+}
+
+func GetRaidArraysSystem(disks []Disk) []RaidArray {
+	mdstat, err := os.ReadFile("/proc/mdstat")
+	if err != nil {
+		// No md driver loaded, nothing to report
+		return []RaidArray{}
+	}
+
+	return ParseRaidArraysLinux(mdstat, disks)
+}
+
+func ParseRaidArraysLinux(mdstat []byte, disks []Disk) []RaidArray {
+	arrays := []RaidArray{}
+	scanner := bufio.NewScanner(bytes.NewReader(mdstat))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != ":" || strings.HasPrefix(line, "Personalities") || strings.HasPrefix(line, "unused") {
+			// Status lines of the last array
+			if len(arrays) > 0 && strings.HasPrefix(line, " ") {
+				ParseRaidStatusLinux(&arrays[len(arrays)-1], line)
+			}
+			continue
+		}
+
+		array := RaidArray{
+			Name:     fields[0],
+			State:    fields[2],
+			Children: []RaidMember{},
+		}
+		array.ID = GetDiskDeviceID(disks, array.Name)
+		if array.ID == "" {
+			array.ID = GetMD5Hash(array.Name)
+		}
+		for _, field := range fields[3:] {
+			if strings.HasPrefix(field, "(") {
+				array.ReadOnly = strings.Contains(field, "read-only")
+				continue
+			}
+			if strings.HasPrefix(field, "raid") || field == "linear" || field == "multipath" {
+				array.Level = field
+				continue
+			}
+
+			match := RaidMemberRegexLinux.FindStringSubmatch(field)
+			if match == nil {
+				continue
+			}
+			array.Children = append(array.Children, RaidMember{
+				ID:    GetDiskDeviceID(disks, match[1]),
+				Name:  match[1],
+				State: RaidMemberStatesLinux[match[2]],
+			})
+		}
+
+		arrays = append(arrays, array)
+	}
+
+	return arrays
+}
+
+func ParseRaidStatusLinux(array *RaidArray, line string) {
+	if strings.Contains(line, " blocks") {
+		fields := strings.Fields(line)
+		blocks, _ := strconv.ParseUint(fields[0], 10, 64)
+		array.Size = blocks * 1024
+		if match := RaidDevicesRegexLinux.FindStringSubmatch(line); match != nil {
+			devices, _ := strconv.ParseUint(match[1], 10, 32)
+			active, _ := strconv.ParseUint(match[2], 10, 32)
+			array.Devices = uint32(devices)
+			array.Active = uint32(active)
+			array.Degraded = active < devices
+		}
+		return
+	}
+
+	match := RaidSyncRegexLinux.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	progress, _ := strconv.ParseFloat(match[2], 32)
+	array.Sync = &RaidSync{
+		Action:   match[1],
+		Progress: float32(progress),
+	}
+	if finishMatch := RaidFinishRegexLinux.FindStringSubmatch(line); finishMatch != nil {
+		array.Sync.Finish = &finishMatch[1]
+	}
+	if speedMatch := RaidSpeedRegexLinux.FindStringSubmatch(line); speedMatch != nil {
+		speed, _ := strconv.ParseUint(speedMatch[1], 10, 64)
+		array.Sync.Speed = speed * 1024
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package main
+
+func GetRaidArraysSystem(disks []Disk) []RaidArray {
+	return []RaidArray{}
+}
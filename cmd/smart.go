@@ -0,0 +1,103 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// WearLevel is the percentage of rated endurance used, Passed is the overall health self-assessment.
+type DiskSmart struct {
+	Passed        *bool            `json:"passed"`
+	Temperature   *float32         `json:"temperature"`
+	PowerOnHours  *uint64          `json:"powerOnHours"`
+	PowerCycles   *uint64          `json:"powerCycles"`
+	RotationRate  *uint32          `json:"rotationRate"`
+	Reallocated   *uint64          `json:"reallocated"`
+	Pending       *uint64          `json:"pending"`
+	Uncorrectable *uint64          `json:"uncorrectable"`
+	WearLevel     *float32         `json:"wearLevel"`
+	Attributes    []SmartAttribute `json:"attributes"`
+	NVMe          *SmartNVMeHealth `json:"nvme"`
+	Timestamp     int64            `json:"timestamp"`
+}
+
+type SmartAttribute struct {
+	ID        uint32 `json:"id"`
+	Name      string `json:"name"`
+	Value     uint32 `json:"value"`
+	Worst     uint32 `json:"worst"`
+	Threshold uint32 `json:"threshold"`
+	Raw       uint64 `json:"raw"`
+}
+
+// Data units are in thousands of 512 byte blocks, as reported by the drive
+type SmartNVMeHealth struct {
+	CriticalWarning         uint32 `json:"criticalWarning"`
+	AvailableSpare          uint32 `json:"availableSpare"`
+	AvailableSpareThreshold uint32 `json:"availableSpareThreshold"`
+	PercentageUsed          uint32 `json:"percentageUsed"`
+	DataUnitsRead           uint64 `json:"dataUnitsRead"`
+	DataUnitsWritten        uint64 `json:"dataUnitsWritten"`
+	UnsafeShutdowns         uint64 `json:"unsafeShutdowns"`
+	MediaErrors             uint64 `json:"mediaErrors"`
+	ErrorLogEntries         uint64 `json:"errorLogEntries"`
+}
+
+type DaemonSmartManager struct {
+	Mutex *sync.Mutex
+	Disks map[string]DiskSmart
+}
+
+func InitSmartManager(handler *Handler) {
+	handler.SmartManager = DaemonSmartManager{
+		Mutex: &sync.Mutex{},
+		Disks: make(map[string]DiskSmart),
+	}
+}
+
+// SMART queries can wake up sleeping disks, so they run on their own much slower cadence than stats
+func RunSmartCollector(handler *Handler) {
+	if !handler.Config.Smart.Enabled {
+		return
+	}
+
+	for {
+		handler.SmartManager.Mutex.Lock()
+		previous := handler.SmartManager.Disks
+		handler.SmartManager.Mutex.Unlock()
+		disks := GetDisksSmart(GetDisks(), previous)
+		handler.SmartManager.Mutex.Lock()
+		handler.SmartManager.Disks = disks
+		handler.SmartManager.Mutex.Unlock()
+
+		time.Sleep(time.Duration(MathMin(int64(handler.Config.Smart.Interval), 60)) * time.Second)
+	}
+}
+
+// Disks that are asleep keep their previous data, the Timestamp tells how old it is.
+func GetDisksSmart(disks []Disk, previous map[string]DiskSmart) map[string]DiskSmart {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetDisksSmartSystem(disks, previous)
+	default:
+		return make(map[string]DiskSmart)
+	}
+}
+
+func AttachDisksSmart(handler *Handler, disks []Disk) []Disk {
+	handler.SmartManager.Mutex.Lock()
+	defer handler.SmartManager.Mutex.Unlock()
+
+	for i, disk := range disks {
+		if smart, ok := handler.SmartManager.Disks[disk.ID]; ok {
+			disks[i].Smart = &smart
+			// sysfs only knows whether a disk spins, not how fast
+			if disk.RotationRate == nil {
+				disks[i].RotationRate = smart.RotationRate
+			}
+		}
+	}
+
+	return disks
+}
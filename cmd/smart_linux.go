@@ -0,0 +1,195 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type SmartLinuxRaw struct {
+	Smartctl struct {
+		Messages []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	PowerMode   string `json:"power_mode"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature *struct {
+		Current float32 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime *struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	PowerCycleCount    *uint64 `json:"power_cycle_count"`
+	RotationRate       *uint32 `json:"rotation_rate"`
+	AtaSmartAttributes *struct {
+		Table []struct {
+			ID     uint32 `json:"id"`
+			Name   string `json:"name"`
+			Value  uint32 `json:"value"`
+			Worst  uint32 `json:"worst"`
+			Thresh uint32 `json:"thresh"`
+			Raw    struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeSmartHealthInformationLog *struct {
+		CriticalWarning         uint32 `json:"critical_warning"`
+		AvailableSpare          uint32 `json:"available_spare"`
+		AvailableSpareThreshold uint32 `json:"available_spare_threshold"`
+		PercentageUsed          uint32 `json:"percentage_used"`
+		DataUnitsRead           uint64 `json:"data_units_read"`
+		DataUnitsWritten        uint64 `json:"data_units_written"`
+		UnsafeShutdowns         uint64 `json:"unsafe_shutdowns"`
+		MediaErrors             uint64 `json:"media_errors"`
+		NumErrLogEntries        uint64 `json:"num_err_log_entries"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+const (
+	SmartAttributeReallocated   = 5
+	SmartAttributeWearLeveling  = 177
+	SmartAttributePending       = 197
+	SmartAttributeUncorrectable = 198
+	SmartAttributeLifeLeft      = 231
+	SmartAttributeMediaWearout  = 233
+)
+
+// Bits 0 and 1 of the smartctl exit status mean the command or the device open failed, the rest only describe the disk
+const SmartExitFatalMask = 0b11
+
+// With -n standby smartctl exits with just bit 1 set instead of waking up a sleeping disk,
+// but the same status also means the device couldn't be opened, so the output has to confirm it
+const SmartExitStandby = 0b10
+
+var ErrSmartStandby = errors.New("disk is in standby")
+
+func GetDisksSmartSystem(disks []Disk, previous map[string]DiskSmart) map[string]DiskSmart {
+	smarts := make(map[string]DiskSmart)
+	if _, err := exec.LookPath("smartctl"); err != nil {
+		SleepyWarnLn("Failed to get SMART data! (smartctl not found)")
+		return smarts
+	}
+
+	for _, disk := range disks {
+		smart, err := GetDiskSmartLinux("/dev/" + disk.Name)
+		if errors.Is(err, ErrSmartStandby) {
+			if last, ok := previous[disk.ID]; ok {
+				smarts[disk.ID] = last
+			}
+			continue
+		}
+		if err != nil {
+			SleepyWarnLn("Failed to get SMART data for %s! (%s)", disk.Name, err.Error())
+			continue
+		}
+		smarts[disk.ID] = smart
+	}
+
+	return smarts
+}
+
+func IsSmartStandby(smartRaw SmartLinuxRaw) bool {
+	powerMode := strings.ToUpper(smartRaw.PowerMode)
+	if powerMode == "STANDBY" || powerMode == "SLEEP" {
+		return true
+	}
+	for _, message := range smartRaw.Smartctl.Messages {
+		if strings.Contains(message.String, "STANDBY") || strings.Contains(message.String, "SLEEP") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetDiskSmartLinux(device string) (DiskSmart, error) {
+	smartStdout, err := exec.Command("smartctl", "--json", "-a", "-n", "standby", device).Output()
+	var smartRaw SmartLinuxRaw
+	parseErr := json.Unmarshal(smartStdout, &smartRaw)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == SmartExitStandby && parseErr == nil && IsSmartStandby(smartRaw) {
+			return DiskSmart{}, ErrSmartStandby
+		}
+		if !errors.As(err, &exitErr) || exitErr.ExitCode()&SmartExitFatalMask != 0 {
+			return DiskSmart{}, err
+		}
+	}
+	if parseErr != nil {
+		return DiskSmart{}, parseErr
+	}
+
+	smart := DiskSmart{
+		PowerCycles:  smartRaw.PowerCycleCount,
+		RotationRate: smartRaw.RotationRate,
+		Attributes:   []SmartAttribute{},
+		Timestamp:    time.Now().UnixMilli(),
+	}
+	if smartRaw.SmartStatus != nil {
+		smart.Passed = &smartRaw.SmartStatus.Passed
+	}
+	if smartRaw.Temperature != nil {
+		smart.Temperature = &smartRaw.Temperature.Current
+	}
+	if smartRaw.PowerOnTime != nil {
+		smart.PowerOnHours = &smartRaw.PowerOnTime.Hours
+	}
+	if smartRaw.AtaSmartAttributes != nil {
+		for _, attributeRaw := range smartRaw.AtaSmartAttributes.Table {
+			attribute := SmartAttribute{
+				ID:        attributeRaw.ID,
+				Name:      attributeRaw.Name,
+				Value:     attributeRaw.Value,
+				Worst:     attributeRaw.Worst,
+				Threshold: attributeRaw.Thresh,
+				Raw:       attributeRaw.Raw.Value,
+			}
+			smart.Attributes = append(smart.Attributes, attribute)
+
+			// Wear attributes count down from 100, so used is the remainder
+			wear := float32(0)
+			if attribute.Value < 100 {
+				wear = float32(100 - attribute.Value)
+			}
+			switch attribute.ID {
+			case SmartAttributeReallocated:
+				smart.Reallocated = &attribute.Raw
+			case SmartAttributePending:
+				smart.Pending = &attribute.Raw
+			case SmartAttributeUncorrectable:
+				smart.Uncorrectable = &attribute.Raw
+			case SmartAttributeWearLeveling, SmartAttributeLifeLeft, SmartAttributeMediaWearout:
+				if smart.WearLevel == nil {
+					smart.WearLevel = &wear
+				}
+			}
+		}
+	}
+	if healthRaw := smartRaw.NVMeSmartHealthInformationLog; healthRaw != nil {
+		smart.NVMe = &SmartNVMeHealth{
+			CriticalWarning:         healthRaw.CriticalWarning,
+			AvailableSpare:          healthRaw.AvailableSpare,
+			AvailableSpareThreshold: healthRaw.AvailableSpareThreshold,
+			PercentageUsed:          healthRaw.PercentageUsed,
+			DataUnitsRead:           healthRaw.DataUnitsRead,
+			DataUnitsWritten:        healthRaw.DataUnitsWritten,
+			UnsafeShutdowns:         healthRaw.UnsafeShutdowns,
+			MediaErrors:             healthRaw.MediaErrors,
+			ErrorLogEntries:         healthRaw.NumErrLogEntries,
+		}
+		wear := float32(healthRaw.PercentageUsed)
+		smart.WearLevel = &wear
+		smart.Uncorrectable = &smart.NVMe.MediaErrors
+	}
+
+	return smart, nil
+}
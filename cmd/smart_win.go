@@ -0,0 +1,59 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"time"
+)
+
+type SmartWindowsRaw struct {
+	UniqueId              string
+	HealthStatus          string
+	Temperature           *float32
+	Wear                  *float32
+	PowerOnHours          *uint64
+	StartStopCycleCount   *uint64
+	ReadErrorsUncorrected *uint64
+}
+
+// smartctl is rarely installed on windows, the storage reliability counters cover the basics
+func GetDisksSmartSystem(disks []Disk, previous map[string]DiskSmart) map[string]DiskSmart {
+	smarts := make(map[string]DiskSmart)
+	smartStdout, err := exec.Command("Powershell", "-Command", "ConvertTo-Json -InputObject @(Get-PhysicalDisk | ForEach-Object { $c = $_ | Get-StorageReliabilityCounter; [PSCustomObject]@{ UniqueId = $_.UniqueId; HealthStatus = [string]$_.HealthStatus; Temperature = $c.Temperature; Wear = $c.Wear; PowerOnHours = $c.PowerOnHours; StartStopCycleCount = $c.StartStopCycleCount; ReadErrorsUncorrected = $c.ReadErrorsUncorrected } })").Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get SMART data! (%s)", err.Error())
+		return smarts
+	}
+
+	var smartsRaw []SmartWindowsRaw
+	err = json.Unmarshal(smartStdout, &smartsRaw)
+	if err != nil {
+		SleepyWarnLn("Failed to parse SMART data! (%s)", err.Error())
+		return smarts
+	}
+
+	for _, disk := range disks {
+		for _, smartRaw := range smartsRaw {
+			if disk.PTUUID == nil || *disk.PTUUID != smartRaw.UniqueId {
+				continue
+			}
+
+			passed := smartRaw.HealthStatus == "Healthy"
+			smarts[disk.ID] = DiskSmart{
+				Passed:        &passed,
+				Temperature:   smartRaw.Temperature,
+				PowerOnHours:  smartRaw.PowerOnHours,
+				PowerCycles:   smartRaw.StartStopCycleCount,
+				Uncorrectable: smartRaw.ReadErrorsUncorrected,
+				WearLevel:     smartRaw.Wear,
+				Attributes:    []SmartAttribute{},
+				Timestamp:     time.Now().UnixMilli(),
+			}
+		}
+	}
+
+	return smarts
+}
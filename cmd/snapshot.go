@@ -1,57 +1,91 @@
-package main
-
-import (
-	"sync"
-	"time"
-)
-
-type HandlerSnapshot struct {
-	Timestamp       time.Time
-	RawCPUUsage     CPUUsageRaw
-	RawDiskUsages   []DiskUsageRaw
-	NetworkUsage    NetworkUsage
-	ContainerUsages []ContainerUsage
-}
-
-type HandlerCache struct {
-	DockerInfo        DockerInfo
-	Containers        []Container
-	ContainerProjects []ContainerProject
-}
-
-func InitSnapshot(handler *Handler) {
-	handler.LastSnapshot.Timestamp = time.Now()
-	handler.LogManager.Containers = make(map[string]DaemonLogItem)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		handler.LastSnapshot.RawCPUUsage = GetCPUUsage()
-	}()
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		handler.LastSnapshot.RawDiskUsages = GetDiskUsages()
-	}()
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		handler.LastSnapshot.NetworkUsage = GetNetworkUsage()
-	}()
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		handler.LastCache.Containers, handler.LastCache.ContainerProjects = GetContainers(handler)
-		handler.LastSnapshot.ContainerUsages = GetContainerUsages(handler)
-	}()
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		handler.LastCache.DockerInfo = GetDockerInfo(handler)
-	}()
-	wg.Wait()
-
-	elapsed := time.Since(handler.LastSnapshot.Timestamp)
-	SleepyLogLn("Built initial snapshot! (took %v ms)", elapsed.Milliseconds())
-}
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+type HandlerSnapshot struct {
+	Timestamp       time.Time
+	RawCPUUsage     CPUUsageRaw
+	RawDiskUsages   []DiskUsageRaw
+	RawNetworkUsage []NetworkInterfaceRaw
+	ContainerUsages []ContainerUsage
+
+	ProcessesTimestamp time.Time
+	RawProcesses       map[int]ProcessRaw
+
+	FilesystemsTimestamp time.Time
+	RawFilesystems       map[string]FilesystemRaw
+}
+
+type HandlerCache struct {
+	Mutex             *sync.Mutex
+	DockerInfo        DockerInfo
+	Containers        []Container
+	ContainerProjects []ContainerProject
+}
+
+// The slices are only ever replaced, never modified in place, so handing them out under the lock is enough
+func GetCachedContainers(handler *Handler) ([]Container, []ContainerProject) {
+	handler.LastCache.Mutex.Lock()
+	defer handler.LastCache.Mutex.Unlock()
+	return handler.LastCache.Containers, handler.LastCache.ContainerProjects
+}
+
+func SetCachedContainers(handler *Handler, containers []Container, containerProjects []ContainerProject) {
+	handler.LastCache.Mutex.Lock()
+	defer handler.LastCache.Mutex.Unlock()
+	handler.LastCache.Containers = containers
+	handler.LastCache.ContainerProjects = containerProjects
+}
+
+func GetCachedDockerInfo(handler *Handler) DockerInfo {
+	handler.LastCache.Mutex.Lock()
+	defer handler.LastCache.Mutex.Unlock()
+	return handler.LastCache.DockerInfo
+}
+
+func SetCachedDockerInfo(handler *Handler, dockerInfo DockerInfo) {
+	handler.LastCache.Mutex.Lock()
+	defer handler.LastCache.Mutex.Unlock()
+	handler.LastCache.DockerInfo = dockerInfo
+}
+
+func InitSnapshot(handler *Handler) {
+	handler.LastSnapshot.Timestamp = time.Now()
+	handler.LogManager.Containers = make(map[string]DaemonLogItem)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.LastSnapshot.RawCPUUsage = GetCPUUsage()
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.LastSnapshot.RawDiskUsages = GetDiskUsages()
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.LastSnapshot.RawNetworkUsage = GetNetworkUsage(handler)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		containers, containerProjects := GetContainers(handler)
+		SetCachedContainers(handler, containers, containerProjects)
+		handler.LastSnapshot.ContainerUsages = GetContainerUsages(handler)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		SetCachedDockerInfo(handler, GetDockerInfo(handler))
+	}()
+	wg.Wait()
+
+	elapsed := time.Since(handler.LastSnapshot.Timestamp)
+	SleepyLogLn("Built initial snapshot! (took %v ms)", elapsed.Milliseconds())
+}
@@ -1,25 +1,87 @@
-package main
-
-import "runtime"
-
-type Software struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
-
-func GetInstalledSoftware() []Software {
-	software := []Software{}
-	zfs := GetZFSVersion()
-	if zfs != nil {
-		software = append(software,
-			Software{"zfs", *zfs},
-		)
-	}
-
-	switch runtime.GOOS {
-	case "linux", "windows":
-		return GetInstalledSoftwareSystem()
-	}
-
-	return software
-}
+package main
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"time"
+)
+
+// A wedged docker daemon would otherwise hang the detection forever
+const SoftwareDetectorTimeout = 10 * time.Second
+
+type Software struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type SoftwareDetector struct {
+	Name    string
+	Command string
+	Args    []string
+	Version *regexp.Regexp
+}
+
+// Some of these print their version to stderr, so both outputs are searched
+var SoftwareDetectors = []SoftwareDetector{
+	{"docker", "docker", []string{"version", "--format", "{{.Server.Version}}"}, regexp.MustCompile(`^(\S+)`)},
+	{"docker-compose", "docker", []string{"compose", "version", "--short"}, regexp.MustCompile(`^v?(\S+)`)},
+	{"docker-compose", "docker-compose", []string{"version", "--short"}, regexp.MustCompile(`^v?(\S+)`)},
+	{"nginx", "nginx", []string{"-v"}, regexp.MustCompile(`nginx/(\S+)`)},
+	{"mysql", "mysqld", []string{"--version"}, regexp.MustCompile(`Ver (\S+)`)},
+	{"mysql", "mysql", []string{"--version"}, regexp.MustCompile(`Distrib ([^,\s]+)|Ver (\d[^,\s]*) for`)},
+	{"samba", "smbd", []string{"--version"}, regexp.MustCompile(`Version (\S+)`)},
+}
+
+func GetInstalledSoftware() []Software {
+	software := []Software{}
+	zfs := GetZFSVersion()
+	if zfs != nil {
+		software = append(software,
+			Software{"zfs", *zfs},
+		)
+	}
+
+	switch runtime.GOOS {
+	case "linux", "windows":
+		software = append(software, GetInstalledSoftwareSystem()...)
+	}
+
+	return software
+}
+
+// Runs the detectors in order, the first one that finds a version wins for each name.
+func DetectSoftware(detectors []SoftwareDetector) []Software {
+	software := []Software{}
+	found := make(map[string]bool)
+	for _, detector := range detectors {
+		if found[detector.Name] {
+			continue
+		}
+		var output bytes.Buffer
+		ctx, cancel := context.WithTimeout(context.Background(), SoftwareDetectorTimeout)
+		cmd := exec.CommandContext(ctx, detector.Command, detector.Args...)
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		err := cmd.Run()
+		cancel()
+		if err != nil {
+			continue
+		}
+		match := detector.Version.FindSubmatch(bytes.TrimSpace(output.Bytes()))
+		if match == nil {
+			continue
+		}
+		for _, version := range match[1:] {
+			if len(version) > 0 {
+				found[detector.Name] = true
+				software = append(software, Software{detector.Name, string(version)})
+				break
+			}
+		}
+	}
+
+	return software
+}
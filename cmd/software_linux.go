@@ -1,8 +1,8 @@
-//go:build linux
-// +build linux
-
-package main
-
-func GetInstalledSoftwareSystem() []Software {
-	return []Software{}
-}
+//go:build linux
+// +build linux
+
+package main
+
+func GetInstalledSoftwareSystem() []Software {
+	return DetectSoftware(SoftwareDetectors)
+}
@@ -1,8 +1,8 @@
-//go:build windows
-// +build windows
-
-package main
-
-func GetInstalledSoftwareSystem() []Software {
-	return []Software{}
-}
+//go:build windows
+// +build windows
+
+package main
+
+func GetInstalledSoftwareSystem() []Software {
+	return DetectSoftware(SoftwareDetectors)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const StatsMinimumInterval = time.Millisecond * 250
+
+type DaemonStatsManager struct {
+	// Sampling updates the last snapshot, so pulled and pushed samples must not overlap
+	Mutex    *sync.Mutex
+	Interval time.Duration
+	Stop     chan struct{}
+	Last     *WebsocketRequestStatsReplyMessage
+}
+
+func InitStatsManager(handler *Handler) {
+	handler.StatsManager = DaemonStatsManager{
+		Mutex: &sync.Mutex{},
+	}
+}
+
+func SampleStats(handler *Handler) WebsocketRequestStatsReplyMessage {
+	handler.StatsManager.Mutex.Lock()
+	defer handler.StatsManager.Mutex.Unlock()
+
+	// Sampling before the first login needs a baseline for the rates
+	if handler.LastSnapshot.Timestamp.IsZero() {
+		InitSnapshot(handler)
+	}
+	stats := GetStatsMessage(handler)
+	handler.StatsManager.Last = &stats
+	return stats
+}
+
+// Replaces any previous subscription, an interval of 0 only stops it.
+func SubscribeStats(handler *Handler, intervalMs uint32) {
+	StopStatsSubscription(handler)
+	if intervalMs == 0 {
+		SleepyLogLn("Stopped pushing stats!")
+		return
+	}
+	interval := time.Duration(intervalMs) * time.Millisecond
+	if interval < StatsMinimumInterval {
+		interval = StatsMinimumInterval
+	}
+
+	stop := make(chan struct{})
+	handler.StatsManager.Mutex.Lock()
+	handler.StatsManager.Interval = interval
+	handler.StatsManager.Stop = stop
+	handler.StatsManager.Mutex.Unlock()
+	SleepyLogLn("Pushing stats every %v ms...", interval.Milliseconds())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				err := SendWebsocketMessage(handler, SampleStats(handler))
+				if err != nil && !errors.Is(err, ErrWebsocketDisconnected) {
+					SleepyWarnLn("Failed to push stats! (%s)", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+func StopStatsSubscription(handler *Handler) {
+	handler.StatsManager.Mutex.Lock()
+	defer handler.StatsManager.Mutex.Unlock()
+	if handler.StatsManager.Stop != nil {
+		close(handler.StatsManager.Stop)
+		handler.StatsManager.Stop = nil
+		handler.StatsManager.Interval = 0
+	}
+}
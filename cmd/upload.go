@@ -1,106 +1,136 @@
-package main
-
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
-	"os"
-)
-
-const (
-	UploadFileDataBackupDatabase string = "BACKUP_DATABASE"
-	UploadFileDataContainerLog   string = "CONTAINER_LOG"
-)
-
-type UploadFileBackupDatabaseData struct {
-	Type     string `json:"type"`
-	Database string `json:"database"`
-	Task     string `json:"task"`
-}
-type UploadFileContainerLogData struct {
-	Type      string `json:"type"`
-	Container string `json:"container"`
-	Task      string `json:"task"`
-}
-
-func UploadFile(handler *Handler, path string, data any) error {
-	dataRaw, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	//prepare the reader instances to encode
-	values := map[string]io.Reader{
-		"data": bytes.NewReader(dataRaw),
-		"file": mustOpen(path),
-	}
-	url := fmt.Sprintf("https://%s/v1/daemon/file/upload", handler.Config.APIHost)
-	err = Upload(handler, url, values)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func Upload(handler *Handler, url string, values map[string]io.Reader) (err error) {
-	// Prepare a form that you will submit to that URL.
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
-	for key, r := range values {
-		var fw io.Writer
-		if x, ok := r.(io.Closer); ok {
-			defer x.Close()
-		}
-		// Add an image file
-		if x, ok := r.(*os.File); ok {
-			if fw, err = w.CreateFormFile(key, x.Name()); err != nil {
-				return
-			}
-		} else {
-			// Add other fields
-			if fw, err = w.CreateFormField(key); err != nil {
-				return
-			}
-		}
-		if _, err = io.Copy(fw, r); err != nil {
-			return err
-		}
-
-	}
-	// Don't forget to close the multipart writer.
-	// If you don't close it, your request will be missing the terminating boundary.
-	w.Close()
-
-	// Now that you have a form, you can submit it to your handler.
-	req, err := http.NewRequest("POST", url, &b)
-	if err != nil {
-		return
-	}
-	// Don't forget to set the content type, this will contain the boundary.
-	req.Header.Set("Cookie", fmt.Sprintf("Token=%s", handler.Config.Token))
-	req.Header.Set("Content-Type", w.FormDataContentType())
-
-	// Submit the request
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return
-	}
-
-	// Check the response
-	if res.StatusCode != http.StatusOK {
-		err = fmt.Errorf("bad status: %s", res.Status)
-	}
-	return
-}
-
-func mustOpen(f string) *os.File {
-	r, err := os.Open(f)
-	if err != nil {
-		panic(err)
-	}
-	return r
-}
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+)
+
+const (
+	UploadFileDataBackupDatabase          string = "BACKUP_DATABASE"
+	UploadFileDataScheduledBackupDatabase string = "SCHEDULED_BACKUP_DATABASE"
+	UploadFileDataBackupVolume            string = "BACKUP_VOLUME"
+	UploadFileDataBinlog                  string = "BINLOG"
+	UploadFileDataContainerLog            string = "CONTAINER_LOG"
+)
+
+type UploadFileBackupDatabaseData struct {
+	Type         string              `json:"type"`
+	Database     string              `json:"database"`
+	Task         string              `json:"task"`
+	Verification *BackupVerification `json:"verification"`
+}
+type UploadFileScheduledBackupDatabaseData struct {
+	Type         string              `json:"type"`
+	Database     string              `json:"database"`
+	Creation     int64               `json:"creation"`
+	Verification *BackupVerification `json:"verification"`
+}
+type UploadFileBackupVolumeData struct {
+	Type   string  `json:"type"`
+	Volume *string `json:"volume"`
+	Path   *string `json:"path"`
+	Task   string  `json:"task"`
+}
+type UploadFileBinlogData struct {
+	Type      string   `json:"type"`
+	Server    string   `json:"server"`
+	Databases []string `json:"databases"`
+	Name      string   `json:"name"`
+}
+type UploadFileContainerLogData struct {
+	Type      string `json:"type"`
+	Container string `json:"container"`
+	Task      string `json:"task"`
+}
+
+func UploadFile(handler *Handler, path string, data any) error {
+	dataRaw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	//prepare the reader instances to encode
+	values := map[string]io.Reader{
+		"data": bytes.NewReader(dataRaw),
+		"file": mustOpen(path),
+	}
+	url := fmt.Sprintf("https://%s/v1/daemon/file/upload", handler.Config.APIHost)
+	err = Upload(handler, url, values)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Upload(handler *Handler, url string, values map[string]io.Reader) (err error) {
+	// Stream the form through a pipe, backups can be far larger than the memory
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(WriteMultipartForm(w, values))
+	}()
+
+	// Now that you have a form, you can submit it to your handler.
+	req, err := http.NewRequest("POST", url, pr)
+	if err != nil {
+		pr.Close()
+		return
+	}
+	// Don't forget to set the content type, this will contain the boundary.
+	req.Header.Set("Cookie", fmt.Sprintf("Token=%s", handler.Config.Token))
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	// Submit the request
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	// Check the response
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("bad status: %s", res.Status)
+	}
+	return
+}
+
+func WriteMultipartForm(w *multipart.Writer, values map[string]io.Reader) (err error) {
+	for key, r := range values {
+		var fw io.Writer
+		if x, ok := r.(io.Closer); ok {
+			defer x.Close()
+		}
+		// Add an image file
+		if x, ok := r.(*os.File); ok {
+			if fw, err = w.CreateFormFile(key, x.Name()); err != nil {
+				return
+			}
+		} else {
+			// Add other fields
+			if fw, err = w.CreateFormField(key); err != nil {
+				return
+			}
+		}
+		if _, err = io.Copy(fw, r); err != nil {
+			return err
+		}
+
+	}
+	// Don't forget to close the multipart writer.
+	// If you don't close it, your request will be missing the terminating boundary.
+	return w.Close()
+}
+
+func mustOpen(f string) *os.File {
+	r, err := os.Open(f)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
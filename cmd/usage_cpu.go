@@ -1,22 +1,94 @@
-package main
-
-import "runtime"
-
-type CPUUsage struct {
-	User   float32 `json:"user"`
-	System float32 `json:"system"`
-}
-
-type CPUUsageRaw struct {
-	User, Nice, System, Idle, Iowait, Irq, Softirq, Steal, Guest, GuestNice, Total uint64
-	CPUCount, StatCount                                                            int
-}
-
-func GetCPUUsage() CPUUsageRaw {
-	switch runtime.GOOS {
-	case "linux", "windows":
-		return GetCPUUsageSystem()
-	default:
-		return CPUUsageRaw{}
-	}
-}
+package main
+
+import "runtime"
+
+type CPUUsage struct {
+	User            float32        `json:"user"`
+	System          float32        `json:"system"`
+	Nice            float32        `json:"nice"`
+	Iowait          float32        `json:"iowait"`
+	Irq             float32        `json:"irq"`
+	Softirq         float32        `json:"softirq"`
+	Steal           float32        `json:"steal"`
+	Cores           []CPUCoreUsage `json:"cores"`
+	Load            CPULoad        `json:"load"`
+	ContextSwitches uint64         `json:"contextSwitches"`
+	Interrupts      uint64         `json:"interrupts"`
+}
+
+type CPUCoreUsage struct {
+	User   float32 `json:"user"`
+	System float32 `json:"system"`
+	Iowait float32 `json:"iowait"`
+	Steal  float32 `json:"steal"`
+}
+
+type CPULoad struct {
+	Load1  float32 `json:"load1"`
+	Load5  float32 `json:"load5"`
+	Load15 float32 `json:"load15"`
+}
+
+type CPUUsageRaw struct {
+	User, Nice, System, Idle, Iowait, Irq, Softirq, Steal, Guest, GuestNice, Total uint64
+	ContextSwitches, Interrupts                                                    uint64
+	CPUCount, StatCount                                                            int
+	Cores                                                                          []CPUUsageRaw
+}
+
+func GetCPUUsage() CPUUsageRaw {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetCPUUsageSystem()
+	default:
+		return CPUUsageRaw{}
+	}
+}
+
+func GetCPULoad() CPULoad {
+	switch runtime.GOOS {
+	case "linux":
+		return GetCPULoadSystem()
+	default:
+		return CPULoad{}
+	}
+}
+
+// Converts the difference between two raw snapshots to percentages of the elapsed CPU time.
+func GetCPUUsagePercentages(current CPUUsageRaw, last CPUUsageRaw) CPUUsage {
+	if current.Total <= last.Total {
+		return CPUUsage{Cores: []CPUCoreUsage{}}
+	}
+	total := float32(current.Total - last.Total)
+	percentage := func(current uint64, last uint64) float32 {
+		if current < last {
+			return 0
+		}
+		return (float32(current-last) / total) * 100
+	}
+
+	usage := CPUUsage{
+		User:    percentage(current.User, last.User),
+		System:  percentage(current.System, last.System),
+		Nice:    percentage(current.Nice, last.Nice),
+		Iowait:  percentage(current.Iowait, last.Iowait),
+		Irq:     percentage(current.Irq, last.Irq),
+		Softirq: percentage(current.Softirq, last.Softirq),
+		Steal:   percentage(current.Steal, last.Steal),
+		Cores:   []CPUCoreUsage{},
+	}
+	for i, core := range current.Cores {
+		if i >= len(last.Cores) {
+			break
+		}
+		coreUsage := GetCPUUsagePercentages(core, last.Cores[i])
+		usage.Cores = append(usage.Cores, CPUCoreUsage{
+			User:   coreUsage.User,
+			System: coreUsage.System,
+			Iowait: coreUsage.Iowait,
+			Steal:  coreUsage.Steal,
+		})
+	}
+
+	return usage
+}
@@ -1,73 +1,131 @@
-//go:build linux
-// +build linux
-
-package main
-
-import (
-	"bufio"
-	"os"
-	"strconv"
-	"strings"
-	"unicode"
-)
-
-type CPUStatLinux struct {
-	Name string
-	Ptr  *uint64
-}
-
-func GetCPUUsageSystem() CPUUsageRaw {
-	file, err := os.Open("/proc/stat")
-	if err != nil {
-		SleepyWarnLn("Failed to get CPU usage! (%s)", err.Error())
-		return CPUUsageRaw{}
-	}
-	defer file.Close()
-
-	var cpu CPUUsageRaw
-	scanner := bufio.NewScanner(file)
-	cpuStats := []CPUStatLinux{
-		{"user", &cpu.User},
-		{"nice", &cpu.Nice},
-		{"system", &cpu.System},
-		{"idle", &cpu.Idle},
-		{"iowait", &cpu.Iowait},
-		{"irq", &cpu.Irq},
-		{"softirq", &cpu.Softirq},
-		{"steal", &cpu.Steal},
-		{"guest", &cpu.Guest},
-		{"guest_nice", &cpu.GuestNice},
-	}
-	if !scanner.Scan() {
-		SleepyWarnLn("Failed to scan /proc/stat!")
-		return CPUUsageRaw{}
-	}
-
-	scanFields := strings.Fields(scanner.Text())[1:]
-	cpu.StatCount = len(scanFields)
-	for i, field := range scanFields {
-		value, err := strconv.ParseUint(field, 10, 64)
-		if err != nil {
-			SleepyWarnLn("Failed to scan %s from /proc/stat!", cpuStats[i].Name)
-			return CPUUsageRaw{}
-		}
-		*cpuStats[i].Ptr = value
-		cpu.Total += value
-	}
-	// included in cpustat[CPUTIME_USER]
-	cpu.Total -= cpu.Guest
-	// included in cpustat[CPUTIME_NICE]
-	cpu.Total -= cpu.GuestNice
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "cpu") && unicode.IsDigit(rune(line[3])) {
-			cpu.CPUCount++
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		SleepyWarnLn("Failed to scan /proc/stat! (%s)", err.Error())
-	}
-
-	return cpu
-}
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type CPUStatLinux struct {
+	Name string
+	Ptr  *uint64
+}
+
+func GetCPUUsageSystem() CPUUsageRaw {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		SleepyWarnLn("Failed to get CPU usage! (%s)", err.Error())
+		return CPUUsageRaw{}
+	}
+	defer file.Close()
+
+	// The intr line lists every interrupt and can get long on big machines
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		SleepyWarnLn("Failed to scan /proc/stat!")
+		return CPUUsageRaw{}
+	}
+	cpu, err := ParseCPUStatLineLinux(scanner.Text())
+	if err != nil {
+		SleepyWarnLn("Failed to scan /proc/stat! (%s)", err.Error())
+		return CPUUsageRaw{}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "cpu") && unicode.IsDigit(rune(line[3])):
+			cpu.CPUCount++
+			core, err := ParseCPUStatLineLinux(line)
+			if err != nil {
+				SleepyWarnLn("Failed to scan %s from /proc/stat! (%s)", fields[0], err.Error())
+				continue
+			}
+			cpu.Cores = append(cpu.Cores, core)
+		case fields[0] == "ctxt":
+			cpu.ContextSwitches, _ = strconv.ParseUint(fields[1], 10, 64)
+		case fields[0] == "intr":
+			// The first number is the total, the rest are per interrupt counts
+			cpu.Interrupts, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		SleepyWarnLn("Failed to scan /proc/stat! (%s)", err.Error())
+	}
+
+	return cpu
+}
+
+func ParseCPUStatLineLinux(line string) (CPUUsageRaw, error) {
+	var cpu CPUUsageRaw
+	cpuStats := []CPUStatLinux{
+		{"user", &cpu.User},
+		{"nice", &cpu.Nice},
+		{"system", &cpu.System},
+		{"idle", &cpu.Idle},
+		{"iowait", &cpu.Iowait},
+		{"irq", &cpu.Irq},
+		{"softirq", &cpu.Softirq},
+		{"steal", &cpu.Steal},
+		{"guest", &cpu.Guest},
+		{"guest_nice", &cpu.GuestNice},
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return CPUUsageRaw{}, errors.New("empty line")
+	}
+
+	scanFields := fields[1:]
+	if len(scanFields) > len(cpuStats) {
+		scanFields = scanFields[:len(cpuStats)]
+	}
+	cpu.StatCount = len(scanFields)
+	for i, field := range scanFields {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return CPUUsageRaw{}, fmt.Errorf("failed to parse %s", cpuStats[i].Name)
+		}
+		*cpuStats[i].Ptr = value
+		cpu.Total += value
+	}
+	// included in cpustat[CPUTIME_USER]
+	cpu.Total -= cpu.Guest
+	// included in cpustat[CPUTIME_NICE]
+	cpu.Total -= cpu.GuestNice
+
+	return cpu, nil
+}
+
+func GetCPULoadSystem() CPULoad {
+	raw, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		SleepyWarnLn("Failed to get CPU load! (%s)", err.Error())
+		return CPULoad{}
+	}
+	fields := strings.Fields(string(raw))
+	if len(fields) < 3 {
+		SleepyWarnLn("Failed to scan /proc/loadavg!")
+		return CPULoad{}
+	}
+
+	load1, _ := strconv.ParseFloat(fields[0], 32)
+	load5, _ := strconv.ParseFloat(fields[1], 32)
+	load15, _ := strconv.ParseFloat(fields[2], 32)
+	return CPULoad{
+		Load1:  float32(load1),
+		Load5:  float32(load5),
+		Load15: float32(load15),
+	}
+}
@@ -1,9 +1,9 @@
-//go:build windows
-// +build windows
-
-package main
-
-// TODO: make windows implementation
-func GetDiskUsagesSystem() []DiskUsageRaw {
-	return []DiskUsageRaw{}
-}
+//go:build windows
+// +build windows
+
+package main
+
+// TODO: make windows implementation
+func GetDiskUsagesSystem() []DiskUsageRaw {
+	return []DiskUsageRaw{}
+}
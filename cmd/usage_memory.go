@@ -1,24 +1,54 @@
-package main
-
-import (
-	"runtime"
-)
-
-type MemoryState struct {
-	Total     uint64 `json:"total"`
-	SwapTotal uint64 `json:"swapTotal"`
-}
-
-type MemoryUsage struct {
-	Used     float32 `json:"used"`
-	SwapUsed float32 `json:"swapUsed"`
-}
-
-func GetMemoryDetails() (MemoryState, MemoryUsage) {
-	switch runtime.GOOS {
-	case "linux", "windows":
-		return GetMemoryDetailsSystem()
-	default:
-		return MemoryState{}, MemoryUsage{}
-	}
-}
+package main
+
+import (
+	"runtime"
+)
+
+type MemoryState struct {
+	Total     uint64 `json:"total"`
+	SwapTotal uint64 `json:"swapTotal"`
+}
+
+type MemoryUsage struct {
+	Used     float32       `json:"used"`
+	SwapUsed float32       `json:"swapUsed"`
+	Details  MemoryDetails `json:"details"`
+}
+
+// Absolute values in bytes, fields a platform doesn't report are left at zero.
+type MemoryDetails struct {
+	Total      uint64          `json:"total"`
+	Used       uint64          `json:"used"`
+	Free       uint64          `json:"free"`
+	Available  uint64          `json:"available"`
+	Buffers    uint64          `json:"buffers"`
+	Cached     uint64          `json:"cached"`
+	Active     uint64          `json:"active"`
+	Inactive   uint64          `json:"inactive"`
+	Shared     uint64          `json:"shared"`
+	Slab       uint64          `json:"slab"`
+	Dirty      uint64          `json:"dirty"`
+	Writeback  uint64          `json:"writeback"`
+	SwapTotal  uint64          `json:"swapTotal"`
+	SwapUsed   uint64          `json:"swapUsed"`
+	SwapFree   uint64          `json:"swapFree"`
+	SwapCached uint64          `json:"swapCached"`
+	HugePages  MemoryHugePages `json:"hugePages"`
+}
+
+type MemoryHugePages struct {
+	Size     uint64 `json:"size"`
+	Total    uint64 `json:"total"`
+	Free     uint64 `json:"free"`
+	Reserved uint64 `json:"reserved"`
+	Surplus  uint64 `json:"surplus"`
+}
+
+func GetMemoryDetails() (MemoryState, MemoryUsage) {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetMemoryDetailsSystem()
+	default:
+		return MemoryState{}, MemoryUsage{}
+	}
+}
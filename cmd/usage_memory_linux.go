@@ -1,91 +1,138 @@
-//go:build linux
-// +build linux
-
-package main
-
-import (
-	"bufio"
-	"os"
-	"strconv"
-	"strings"
-)
-
-type MemoryLinuxRaw struct {
-	Total, Used, Buffers, Cached, Free, Available, Active, Inactive,
-	SwapTotal, SwapUsed, SwapCached, SwapFree uint64
-	MemAvailableEnabled bool
-}
-
-type MemoryStatLinux struct {
-	Name string
-	Ptr  *uint64
-}
-
-func GetMemorySystem() (MemoryLinuxRaw, error) {
-	file, err := os.Open("/proc/meminfo")
-	if err != nil {
-		return MemoryLinuxRaw{}, err
-	}
-	defer file.Close()
-
-	var memory MemoryLinuxRaw
-	scanner := bufio.NewScanner(file)
-	memStats := []MemoryStatLinux{
-		{"MemTotal", &memory.Total},
-		{"MemFree", &memory.Free},
-		{"MemAvailable", &memory.Available},
-		{"Buffers", &memory.Buffers},
-		{"Cached", &memory.Cached},
-		{"Active", &memory.Active},
-		{"Inactive", &memory.Inactive},
-		{"SwapCached", &memory.SwapCached},
-		{"SwapTotal", &memory.SwapTotal},
-		{"SwapFree", &memory.SwapFree},
-	}
-	for scanner.Scan() {
-		line := scanner.Text()
-		i := strings.IndexRune(line, ':')
-		if i < 0 {
-			continue
-		}
-		fld := line[:i]
-		for j, stat := range memStats {
-			if stat.Name == fld {
-				val := strings.TrimSpace(strings.TrimRight(line[i+1:], "kB"))
-				if v, err := strconv.ParseUint(val, 10, 64); err == nil {
-					*memStats[j].Ptr = v * 1024
-				}
-			}
-		}
-		if fld == "MemAvailable" {
-			memory.MemAvailableEnabled = true
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return MemoryLinuxRaw{}, err
-	}
-
-	memory.SwapUsed = memory.SwapTotal - memory.SwapFree
-	if memory.MemAvailableEnabled {
-		memory.Used = memory.Total - memory.Available
-	} else {
-		memory.Used = memory.Total - memory.Free - memory.Buffers - memory.Cached
-	}
-
-	return memory, nil
-}
-
-func GetMemoryDetailsSystem() (MemoryState, MemoryUsage) {
-	memory, err := GetMemorySystem()
-	if err != nil {
-		SleepyWarnLn("Failed to get memory details! (%s)", err.Error())
-		return MemoryState{}, MemoryUsage{}
-	}
-	return MemoryState{
-			Total:     memory.Total,
-			SwapTotal: memory.SwapTotal,
-		}, MemoryUsage{
-			Used:     (float32(memory.Used) / float32(memory.Total+1)) * 100,
-			SwapUsed: (float32(memory.SwapUsed) / float32(memory.SwapTotal+1)) * 100,
-		}
-}
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type MemoryLinuxRaw struct {
+	Total, Used, Buffers, Cached, Free, Available, Active, Inactive,
+	SwapTotal, SwapUsed, SwapCached, SwapFree uint64
+
+	Shared, Slab, Dirty, Writeback uint64
+
+	HugePagesSize, HugePagesTotal, HugePagesFree, HugePagesReserved, HugePagesSurplus uint64
+
+	MemAvailableEnabled bool
+}
+
+type MemoryStatLinux struct {
+	Name string
+	Ptr  *uint64
+}
+
+func GetMemorySystem() (MemoryLinuxRaw, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return MemoryLinuxRaw{}, err
+	}
+	defer file.Close()
+
+	memory := MemoryLinuxRaw{}
+	scanner := bufio.NewScanner(file)
+	memStats := []MemoryStatLinux{
+		{"MemTotal", &memory.Total},
+		{"MemFree", &memory.Free},
+		{"MemAvailable", &memory.Available},
+		{"Buffers", &memory.Buffers},
+		{"Cached", &memory.Cached},
+		{"Active", &memory.Active},
+		{"Inactive", &memory.Inactive},
+		{"SwapCached", &memory.SwapCached},
+		{"SwapTotal", &memory.SwapTotal},
+		{"SwapFree", &memory.SwapFree},
+		{"Shmem", &memory.Shared},
+		{"Slab", &memory.Slab},
+		{"Dirty", &memory.Dirty},
+		{"Writeback", &memory.Writeback},
+		{"Hugepagesize", &memory.HugePagesSize},
+		{"HugePages_Total", &memory.HugePagesTotal},
+		{"HugePages_Free", &memory.HugePagesFree},
+		{"HugePages_Rsvd", &memory.HugePagesReserved},
+		{"HugePages_Surp", &memory.HugePagesSurplus},
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.IndexRune(line, ':')
+		if i < 0 {
+			continue
+		}
+		fld := line[:i]
+		// Page counts like HugePages_Total have no unit, everything else is in kB
+		val := strings.TrimSpace(line[i+1:])
+		multiplier := uint64(1)
+		if strings.HasSuffix(val, "kB") {
+			val = strings.TrimSpace(strings.TrimSuffix(val, "kB"))
+			multiplier = 1024
+		}
+		v, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			continue
+		}
+		for j, stat := range memStats {
+			if stat.Name == fld {
+				*memStats[j].Ptr = v * multiplier
+			}
+		}
+		if fld == "MemAvailable" {
+			memory.MemAvailableEnabled = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return MemoryLinuxRaw{}, err
+	}
+
+	memory.SwapUsed = memory.SwapTotal - memory.SwapFree
+	if memory.MemAvailableEnabled {
+		memory.Used = memory.Total - memory.Available
+	} else {
+		memory.Used = memory.Total - memory.Free - memory.Buffers - memory.Cached
+	}
+
+	return memory, nil
+}
+
+func GetMemoryDetailsSystem() (MemoryState, MemoryUsage) {
+	memory, err := GetMemorySystem()
+	if err != nil {
+		SleepyWarnLn("Failed to get memory details! (%s)", err.Error())
+		return MemoryState{}, MemoryUsage{}
+	}
+	return MemoryState{
+		Total:     memory.Total,
+		SwapTotal: memory.SwapTotal,
+	}, MemoryUsage{
+		Used:     (float32(memory.Used) / float32(memory.Total+1)) * 100,
+		SwapUsed: (float32(memory.SwapUsed) / float32(memory.SwapTotal+1)) * 100,
+		Details: MemoryDetails{
+			Total:      memory.Total,
+			Used:       memory.Used,
+			Free:       memory.Free,
+			Available:  memory.Available,
+			Buffers:    memory.Buffers,
+			Cached:     memory.Cached,
+			Active:     memory.Active,
+			Inactive:   memory.Inactive,
+			Shared:     memory.Shared,
+			Slab:       memory.Slab,
+			Dirty:      memory.Dirty,
+			Writeback:  memory.Writeback,
+			SwapTotal:  memory.SwapTotal,
+			SwapUsed:   memory.SwapUsed,
+			SwapFree:   memory.SwapFree,
+			SwapCached: memory.SwapCached,
+			HugePages: MemoryHugePages{
+				Size:     memory.HugePagesSize,
+				Total:    memory.HugePagesTotal,
+				Free:     memory.HugePagesFree,
+				Reserved: memory.HugePagesReserved,
+				Surplus:  memory.HugePagesSurplus,
+			},
+		},
+	}
+}
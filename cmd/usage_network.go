@@ -1,19 +1,127 @@
-package main
-
-import (
-	"runtime"
-)
-
-type NetworkUsage struct {
-	RX uint64 `json:"rx"`
-	TX uint64 `json:"tx"`
-}
-
-func GetNetworkUsage() NetworkUsage {
-	switch runtime.GOOS {
-	case "linux", "windows":
-		return GetNetworkUsageSystem()
-	default:
-		return NetworkUsage{}
-	}
-}
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"runtime"
+)
+
+type NetworkUsage struct {
+	RX         uint64                  `json:"rx"`
+	TX         uint64                  `json:"tx"`
+	Interfaces []NetworkInterfaceUsage `json:"interfaces"`
+}
+
+type NetworkInterfaceUsage struct {
+	Name      string   `json:"name"`
+	RX        uint64   `json:"rx"`
+	TX        uint64   `json:"tx"`
+	RXPackets uint64   `json:"rxPackets"`
+	TXPackets uint64   `json:"txPackets"`
+	RXErrors  uint64   `json:"rxErrors"`
+	TXErrors  uint64   `json:"txErrors"`
+	RXDrops   uint64   `json:"rxDrops"`
+	TXDrops   uint64   `json:"txDrops"`
+	Speed     int64    `json:"speed"`
+	State     string   `json:"state"`
+	MAC       string   `json:"mac"`
+	Addresses []string `json:"addresses"`
+}
+
+type NetworkInterfaceRaw struct {
+	Name                                  string
+	RXBytes, RXPackets, RXErrors, RXDrops uint64
+	TXBytes, TXPackets, TXErrors, TXDrops uint64
+	Speed                                 int64
+	State, MAC                            string
+}
+
+func GetNetworkUsage(handler *Handler) []NetworkInterfaceRaw {
+	var interfaces []NetworkInterfaceRaw
+	switch runtime.GOOS {
+	case "linux", "windows":
+		interfaces = GetNetworkUsageSystem()
+	default:
+		return []NetworkInterfaceRaw{}
+	}
+
+	selected := []NetworkInterfaceRaw{}
+	for _, networkInterface := range interfaces {
+		if IsNetworkInterfaceSelected(handler, networkInterface.Name) {
+			selected = append(selected, networkInterface)
+		}
+	}
+	return selected
+}
+
+func IsNetworkInterfaceSelected(handler *Handler, name string) bool {
+	for _, pattern := range handler.Config.Network.Exclude {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return false
+		}
+	}
+	for _, pattern := range handler.Config.Network.Include {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Converts counters of two snapshots taken seconds apart to per second rates.
+func GetNetworkUsageRates(current []NetworkInterfaceRaw, last []NetworkInterfaceRaw, seconds float64) NetworkUsage {
+	usage := NetworkUsage{
+		Interfaces: []NetworkInterfaceUsage{},
+	}
+	for _, networkInterface := range current {
+		lastIndex := -1
+		for i, lastInterface := range last {
+			if lastInterface.Name == networkInterface.Name {
+				lastIndex = i
+			}
+		}
+		if lastIndex == -1 {
+			continue
+		}
+		lastInterface := last[lastIndex]
+
+		interfaceUsage := NetworkInterfaceUsage{
+			Name:      networkInterface.Name,
+			RX:        MathRate(MathDeltaUint(networkInterface.RXBytes, lastInterface.RXBytes), seconds),
+			TX:        MathRate(MathDeltaUint(networkInterface.TXBytes, lastInterface.TXBytes), seconds),
+			RXPackets: MathRate(MathDeltaUint(networkInterface.RXPackets, lastInterface.RXPackets), seconds),
+			TXPackets: MathRate(MathDeltaUint(networkInterface.TXPackets, lastInterface.TXPackets), seconds),
+			RXErrors:  MathRate(MathDeltaUint(networkInterface.RXErrors, lastInterface.RXErrors), seconds),
+			TXErrors:  MathRate(MathDeltaUint(networkInterface.TXErrors, lastInterface.TXErrors), seconds),
+			RXDrops:   MathRate(MathDeltaUint(networkInterface.RXDrops, lastInterface.RXDrops), seconds),
+			TXDrops:   MathRate(MathDeltaUint(networkInterface.TXDrops, lastInterface.TXDrops), seconds),
+			Speed:     networkInterface.Speed,
+			State:     networkInterface.State,
+			MAC:       networkInterface.MAC,
+			Addresses: GetNetworkInterfaceAddresses(networkInterface.Name),
+		}
+		usage.RX += interfaceUsage.RX
+		usage.TX += interfaceUsage.TX
+		usage.Interfaces = append(usage.Interfaces, interfaceUsage)
+	}
+
+	return usage
+}
+
+func GetNetworkInterfaceAddresses(name string) []string {
+	addresses := []string{}
+	networkInterface, err := net.InterfaceByName(name)
+	if err != nil {
+		return addresses
+	}
+	interfaceAddresses, err := networkInterface.Addrs()
+	if err != nil {
+		return addresses
+	}
+	for _, address := range interfaceAddresses {
+		addresses = append(addresses, address.String())
+	}
+
+	return addresses
+}
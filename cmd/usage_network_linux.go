@@ -1,43 +1,72 @@
-//go:build linux
-// +build linux
-
-package main
-
-import (
-	"bufio"
-	"os"
-	"strconv"
-	"strings"
-)
-
-func GetNetworkUsageSystem() NetworkUsage {
-	file, err := os.Open("/proc/net/dev")
-	if err != nil {
-		SleepyWarnLn("Failed to get network usage! (%s)", err.Error())
-		return NetworkUsage{}
-	}
-	defer file.Close()
-
-	var network NetworkUsage
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		i := strings.IndexRune(line, ':')
-		if i < 0 {
-			continue
-		}
-		name := strings.TrimSpace(line[:i])
-		if name != "eth0" && name != "wlan0" {
-			continue
-		}
-
-		fields := strings.Fields(line[i+1:])
-		rx, _ := strconv.ParseUint(fields[0], 10, 64)
-		tx, _ := strconv.ParseUint(fields[8], 10, 64)
-
-		network.RX += rx
-		network.TX += tx
-	}
-
-	return network
-}
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func GetNetworkUsageSystem() []NetworkInterfaceRaw {
+	file, err := os.Open("/proc/net/dev")
+	if err != nil {
+		SleepyWarnLn("Failed to get network usage! (%s)", err.Error())
+		return []NetworkInterfaceRaw{}
+	}
+	defer file.Close()
+
+	// https://www.kernel.org/doc/html/latest/networking/statistics.html#procfs
+	interfaces := []NetworkInterfaceRaw{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.IndexRune(line, ':')
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(line[i+1:])
+		if len(fields) < 12 {
+			continue
+		}
+		values := make([]uint64, len(fields))
+		for j, field := range fields {
+			values[j], _ = strconv.ParseUint(field, 10, 64)
+		}
+
+		name := strings.TrimSpace(line[:i])
+		networkInterface := NetworkInterfaceRaw{
+			Name:      name,
+			RXBytes:   values[0],
+			RXPackets: values[1],
+			RXErrors:  values[2],
+			RXDrops:   values[3],
+			TXBytes:   values[8],
+			TXPackets: values[9],
+			TXErrors:  values[10],
+			TXDrops:   values[11],
+			Speed:     -1,
+		}
+
+		// Speed can't be read while the link is down, so it stays unknown
+		sysPath := filepath.Join("/sys/class/net", name)
+		if raw, err := os.ReadFile(filepath.Join(sysPath, "speed")); err == nil {
+			if speed, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64); err == nil {
+				networkInterface.Speed = speed
+			}
+		}
+		if raw, err := os.ReadFile(filepath.Join(sysPath, "operstate")); err == nil {
+			networkInterface.State = strings.TrimSpace(string(raw))
+		}
+		if raw, err := os.ReadFile(filepath.Join(sysPath, "address")); err == nil {
+			networkInterface.MAC = strings.TrimSpace(string(raw))
+		}
+
+		interfaces = append(interfaces, networkInterface)
+	}
+
+	return interfaces
+}
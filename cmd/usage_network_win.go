@@ -1,42 +1,79 @@
-//go:build windows
-// +build windows
-
-package main
-
-import (
-	"encoding/json"
-	"os/exec"
-)
-
-type NetworkAdapterWindowsRaw struct {
-	InterfaceAlias string
-	ReceivedBytes  uint64
-	SentBytes      uint64
-}
-
-func GetNetworkUsageSystem() NetworkUsage {
-	networkAdaptersStdout, err := exec.Command("Powershell", "-Command", "Get-NetAdapterStatistics | ConvertTo-Json").Output()
-	if err != nil {
-		SleepyWarnLn("Failed to get network adapters! (%s)", err.Error())
-		return NetworkUsage{}
-	}
-
-	var networkAdaptersRaw []NetworkAdapterWindowsRaw
-	err = json.Unmarshal(networkAdaptersStdout, &networkAdaptersRaw)
-	if err != nil {
-		SleepyWarnLn("Failed to parse network adapters! (%s)", err.Error())
-		return NetworkUsage{}
-	}
-
-	var network NetworkUsage
-	for _, adapter := range networkAdaptersRaw {
-		if adapter.InterfaceAlias != "Ethernet" {
-			continue
-		}
-
-		network.RX += adapter.ReceivedBytes
-		network.TX += adapter.SentBytes
-	}
-
-	return network
-}
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+)
+
+type NetworkAdapterWindowsRaw struct {
+	InterfaceAlias           string
+	ReceivedBytes            uint64
+	SentBytes                uint64
+	ReceivedUnicastPackets   uint64
+	SentUnicastPackets       uint64
+	ReceivedDiscardedPackets uint64
+	OutboundDiscardedPackets uint64
+	ReceivedPacketErrors     uint64
+	OutboundPacketErrors     uint64
+}
+
+type NetworkAdapterDetailsWindowsRaw struct {
+	Name       string
+	Status     string
+	MacAddress string
+	Speed      int64
+}
+
+// ConvertTo-Json turns a single piped object into a bare object, so the arrays are passed with -InputObject
+func GetNetworkUsageSystem() []NetworkInterfaceRaw {
+	networkAdaptersStdout, err := exec.Command("Powershell", "-Command", "ConvertTo-Json -InputObject @(Get-NetAdapterStatistics)").Output()
+	if err != nil {
+		SleepyWarnLn("Failed to get network adapters! (%s)", err.Error())
+		return []NetworkInterfaceRaw{}
+	}
+
+	var networkAdaptersRaw []NetworkAdapterWindowsRaw
+	err = json.Unmarshal(networkAdaptersStdout, &networkAdaptersRaw)
+	if err != nil {
+		SleepyWarnLn("Failed to parse network adapters! (%s)", err.Error())
+		return []NetworkInterfaceRaw{}
+	}
+
+	var networkAdapterDetailsRaw []NetworkAdapterDetailsWindowsRaw
+	networkAdapterDetailsStdout, err := exec.Command("Powershell", "-Command", "ConvertTo-Json -InputObject @(Get-NetAdapter | Select-Object Name,Status,MacAddress,Speed)").Output()
+	if err == nil {
+		json.Unmarshal(networkAdapterDetailsStdout, &networkAdapterDetailsRaw)
+	}
+
+	interfaces := []NetworkInterfaceRaw{}
+	for _, adapter := range networkAdaptersRaw {
+		networkInterface := NetworkInterfaceRaw{
+			Name:      adapter.InterfaceAlias,
+			RXBytes:   adapter.ReceivedBytes,
+			RXPackets: adapter.ReceivedUnicastPackets,
+			RXErrors:  adapter.ReceivedPacketErrors,
+			RXDrops:   adapter.ReceivedDiscardedPackets,
+			TXBytes:   adapter.SentBytes,
+			TXPackets: adapter.SentUnicastPackets,
+			TXErrors:  adapter.OutboundPacketErrors,
+			TXDrops:   adapter.OutboundDiscardedPackets,
+			Speed:     -1,
+		}
+		for _, details := range networkAdapterDetailsRaw {
+			if details.Name == adapter.InterfaceAlias {
+				// Windows reports bits per second, Linux megabits
+				networkInterface.Speed = details.Speed / 1000000
+				networkInterface.State = strings.ToLower(details.Status)
+				networkInterface.MAC = strings.ToLower(strings.ReplaceAll(details.MacAddress, "-", ":"))
+			}
+		}
+
+		interfaces = append(interfaces, networkInterface)
+	}
+
+	return interfaces
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"runtime"
+)
+
+// Pressure stall information, averages are the percentage of time tasks were stalled.
+// https://docs.kernel.org/accounting/psi.html
+type PressureUsage struct {
+	CPU    *PressureResource `json:"cpu"`
+	Memory *PressureResource `json:"memory"`
+	IO     *PressureResource `json:"io"`
+}
+
+type PressureResource struct {
+	Some PressureAverages  `json:"some"`
+	Full *PressureAverages `json:"full"`
+}
+
+type PressureAverages struct {
+	Avg10  float32 `json:"avg10"`
+	Avg60  float32 `json:"avg60"`
+	Avg300 float32 `json:"avg300"`
+	Total  uint64  `json:"total"`
+}
+
+func GetPressureUsage() PressureUsage {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetPressureUsageSystem()
+	default:
+		return PressureUsage{}
+	}
+}
@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Kernels without CONFIG_PSI don't have /proc/pressure, which leaves the resources empty
+func GetPressureUsageSystem() PressureUsage {
+	return PressureUsage{
+		CPU:    GetPressureResourceLinux("/proc/pressure/cpu"),
+		Memory: GetPressureResourceLinux("/proc/pressure/memory"),
+		IO:     GetPressureResourceLinux("/proc/pressure/io"),
+	}
+}
+
+func GetPressureResourceLinux(path string) *PressureResource {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	resource := PressureResource{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 1 {
+			continue
+		}
+		averages := ParsePressureAveragesLinux(fields[1:])
+		switch fields[0] {
+		case "some":
+			resource.Some = averages
+		case "full":
+			// The CPU full line is always zero on the system level, but it's reported anyway
+			resource.Full = &averages
+		}
+	}
+
+	return &resource
+}
+
+func ParsePressureAveragesLinux(fields []string) PressureAverages {
+	averages := PressureAverages{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		parsed, _ := strconv.ParseFloat(value, 32)
+		switch key {
+		case "avg10":
+			averages.Avg10 = float32(parsed)
+		case "avg60":
+			averages.Avg60 = float32(parsed)
+		case "avg300":
+			averages.Avg300 = float32(parsed)
+		case "total":
+			averages.Total, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+
+	return averages
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package main
+
+// Windows has no equivalent of pressure stall information
+func GetPressureUsageSystem() PressureUsage {
+	return PressureUsage{}
+}
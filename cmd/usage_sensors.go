@@ -0,0 +1,32 @@
+package main
+
+import (
+	"runtime"
+)
+
+const (
+	SensorTypeTemperature string = "TEMPERATURE"
+	SensorTypeFan         string = "FAN"
+	SensorTypeVoltage     string = "VOLTAGE"
+	SensorTypeCurrent     string = "CURRENT"
+	SensorTypePower       string = "POWER"
+)
+
+// Values are in °C, RPM, V, A and W, depending on the type.
+type Sensor struct {
+	Chip     string   `json:"chip"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Value    float64  `json:"value"`
+	Max      *float64 `json:"max"`
+	Critical *float64 `json:"critical"`
+}
+
+func GetSensors(handler *Handler) []Sensor {
+	switch runtime.GOOS {
+	case "linux", "windows":
+		return GetSensorsSystem(handler)
+	default:
+		return []Sensor{}
+	}
+}
@@ -0,0 +1,198 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type SensorKindLinux struct {
+	Type    string
+	Prefix  string
+	Divisor float64
+}
+
+// https://www.kernel.org/doc/html/latest/hwmon/sysfs-interface.html
+var SensorKindsLinux = []SensorKindLinux{
+	{Type: SensorTypeTemperature, Prefix: "temp", Divisor: 1000},
+	{Type: SensorTypeFan, Prefix: "fan", Divisor: 1},
+	{Type: SensorTypeVoltage, Prefix: "in", Divisor: 1000},
+	{Type: SensorTypeCurrent, Prefix: "curr", Divisor: 1000},
+	{Type: SensorTypePower, Prefix: "power", Divisor: 1000000},
+}
+
+var SensorInputRegexLinux = regexp.MustCompile(`^(temp|fan|in|curr|power)(\d+)_(input|average)$`)
+
+func GetSensorsSystem(handler *Handler) []Sensor {
+	root := handler.Config.Sensors.SysfsRoot
+	if root == "" {
+		root = "/sys"
+	}
+	sensors := GetHwmonSensorsLinux(filepath.Join(root, "class", "hwmon"))
+	sensors = append(sensors, GetThermalSensorsLinux(filepath.Join(root, "class", "thermal"))...)
+
+	return sensors
+}
+
+func GetHwmonSensorsLinux(path string) []Sensor {
+	sensors := []Sensor{}
+	chips, err := os.ReadDir(path)
+	if err != nil {
+		return sensors
+	}
+	chipNames := make(map[string]string)
+	chipCounts := make(map[string]int)
+	for _, chip := range chips {
+		chipName := ReadSysfsStringLinux(filepath.Join(path, chip.Name(), "name"))
+		if chipName == "" {
+			chipName = chip.Name()
+		}
+		chipNames[chip.Name()] = chipName
+		chipCounts[chipName]++
+	}
+	for _, chip := range chips {
+		chipPath := filepath.Join(path, chip.Name())
+		chipName := chipNames[chip.Name()]
+		// Two disks both report as nvme or drivetemp, the device they belong to tells them apart
+		// and unlike the hwmon number it doesn't change between boots
+		if chipCounts[chipName] > 1 {
+			device := chip.Name()
+			if devicePath, err := filepath.EvalSymlinks(filepath.Join(chipPath, "device")); err == nil {
+				device = filepath.Base(devicePath)
+			}
+			chipName += "-" + device
+		}
+
+		// Older drivers expose the attributes on the device instead of the hwmon node
+		for _, attributesPath := range []string{chipPath, filepath.Join(chipPath, "device")} {
+			entries, err := os.ReadDir(attributesPath)
+			if err != nil {
+				continue
+			}
+			seen := make(map[string]bool)
+			for _, entry := range entries {
+				match := SensorInputRegexLinux.FindStringSubmatch(entry.Name())
+				if match == nil || seen[match[1]+match[2]] {
+					continue
+				}
+				seen[match[1]+match[2]] = true
+				sensor, ok := ReadHwmonSensorLinux(attributesPath, chipName, match[1], match[2])
+				if ok {
+					sensors = append(sensors, sensor)
+				}
+			}
+		}
+	}
+	sort.SliceStable(sensors, func(i, j int) bool {
+		if sensors[i].Chip != sensors[j].Chip {
+			return sensors[i].Chip < sensors[j].Chip
+		}
+		return sensors[i].Label < sensors[j].Label
+	})
+
+	return sensors
+}
+
+func ReadHwmonSensorLinux(path string, chip string, prefix string, index string) (Sensor, bool) {
+	var kind SensorKindLinux
+	for _, sensorKind := range SensorKindsLinux {
+		if sensorKind.Prefix == prefix {
+			kind = sensorKind
+		}
+	}
+	base := filepath.Join(path, prefix+index)
+	value, ok := ReadSysfsValueLinux(base+"_input", kind.Divisor)
+	if !ok {
+		// Power is often only reported as an average
+		value, ok = ReadSysfsValueLinux(base+"_average", kind.Divisor)
+		if !ok {
+			return Sensor{}, false
+		}
+	}
+	label := ReadSysfsStringLinux(base + "_label")
+	if label == "" {
+		label = prefix + index
+	}
+
+	sensor := Sensor{
+		Chip:  chip,
+		Label: label,
+		Type:  kind.Type,
+		Value: value,
+	}
+	if max, ok := ReadSysfsValueLinux(base+"_max", kind.Divisor); ok {
+		sensor.Max = &max
+	}
+	if critical, ok := ReadSysfsValueLinux(base+"_crit", kind.Divisor); ok {
+		sensor.Critical = &critical
+	}
+
+	return sensor, true
+}
+
+func GetThermalSensorsLinux(path string) []Sensor {
+	sensors := []Sensor{}
+	zones, err := filepath.Glob(filepath.Join(path, "thermal_zone*"))
+	if err != nil {
+		return sensors
+	}
+	sort.Strings(zones)
+	for _, zonePath := range zones {
+		value, ok := ReadSysfsValueLinux(filepath.Join(zonePath, "temp"), 1000)
+		if !ok {
+			continue
+		}
+		label := ReadSysfsStringLinux(filepath.Join(zonePath, "type"))
+		if label == "" {
+			label = filepath.Base(zonePath)
+		}
+		sensor := Sensor{
+			Chip:  filepath.Base(zonePath),
+			Label: label,
+			Type:  SensorTypeTemperature,
+			Value: value,
+		}
+
+		// Trip points have no fixed order, so look for the critical one
+		trips, _ := filepath.Glob(filepath.Join(zonePath, "trip_point_*_type"))
+		for _, trip := range trips {
+			if ReadSysfsStringLinux(trip) != "critical" {
+				continue
+			}
+			if critical, ok := ReadSysfsValueLinux(strings.TrimSuffix(trip, "_type")+"_temp", 1000); ok {
+				sensor.Critical = &critical
+			}
+		}
+		sensors = append(sensors, sensor)
+	}
+
+	return sensors
+}
+
+func ReadSysfsStringLinux(path string) string {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(raw))
+}
+
+func ReadSysfsValueLinux(path string, divisor float64) (float64, bool) {
+	raw := ReadSysfsStringLinux(path)
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return float64(value) / divisor, true
+}
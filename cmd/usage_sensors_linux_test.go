@@ -0,0 +1,101 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Lays out files relative to root, creating the directories on the way
+func writeSysfsFixture(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetSensorsSystemFixture(t *testing.T) {
+	root := t.TempDir()
+	writeSysfsFixture(t, root, map[string]string{
+		"class/hwmon/hwmon0/name":                        "coretemp",
+		"class/hwmon/hwmon0/temp1_input":                 "45000",
+		"class/hwmon/hwmon0/temp1_label":                 "Package id 0",
+		"class/hwmon/hwmon0/temp1_max":                   "80000",
+		"class/hwmon/hwmon0/temp1_crit":                  "100000",
+		"class/hwmon/hwmon1/name":                        "nvme",
+		"class/hwmon/hwmon1/temp1_input":                 "38850",
+		"class/hwmon/hwmon2/name":                        "nvme",
+		"class/hwmon/hwmon2/temp1_input":                 "41850",
+		"class/hwmon/hwmon3/name":                        "nct6775",
+		"class/hwmon/hwmon3/fan1_input":                  "1200",
+		"class/hwmon/hwmon3/in0_input":                   "12000",
+		"class/hwmon/hwmon3/curr1_input":                 "1500",
+		"class/hwmon/hwmon3/power1_average":              "5000000",
+		"class/hwmon/hwmon3/temp2_max":                   "90000",
+		"class/hwmon/hwmon4/device/temp1_input":          "30000",
+		"class/thermal/thermal_zone0/type":               "x86_pkg_temp",
+		"class/thermal/thermal_zone0/temp":               "50000",
+		"class/thermal/thermal_zone0/trip_point_0_type":  "passive",
+		"class/thermal/thermal_zone0/trip_point_0_temp":  "95000",
+		"class/thermal/thermal_zone0/trip_point_1_type":  "critical",
+		"class/thermal/thermal_zone0/trip_point_1_temp":  "105000",
+		"devices/pci0000:00/0000:00:01.0/nvme/nvme0/dev": "259:0",
+		"devices/pci0000:00/0000:00:02.0/nvme/nvme1/dev": "259:1",
+	})
+	// Both nvme chips share a name, only their devices differ
+	for hwmon, device := range map[string]string{"hwmon1": "0000:00:01.0/nvme/nvme0", "hwmon2": "0000:00:02.0/nvme/nvme1"} {
+		err := os.Symlink(filepath.Join(root, "devices/pci0000:00", device), filepath.Join(root, "class/hwmon", hwmon, "device"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var handler Handler
+	handler.Config.Sensors.SysfsRoot = root
+	sensors := GetSensorsSystem(&handler)
+
+	expected := []Sensor{
+		{Chip: "coretemp", Label: "Package id 0", Type: SensorTypeTemperature, Value: 45},
+		{Chip: "hwmon4", Label: "temp1", Type: SensorTypeTemperature, Value: 30},
+		{Chip: "nct6775", Label: "curr1", Type: SensorTypeCurrent, Value: 1.5},
+		{Chip: "nct6775", Label: "fan1", Type: SensorTypeFan, Value: 1200},
+		{Chip: "nct6775", Label: "in0", Type: SensorTypeVoltage, Value: 12},
+		{Chip: "nct6775", Label: "power1", Type: SensorTypePower, Value: 5},
+		{Chip: "nvme-nvme0", Label: "temp1", Type: SensorTypeTemperature, Value: 38.85},
+		{Chip: "nvme-nvme1", Label: "temp1", Type: SensorTypeTemperature, Value: 41.85},
+		{Chip: "thermal_zone0", Label: "x86_pkg_temp", Type: SensorTypeTemperature, Value: 50},
+	}
+	if len(sensors) != len(expected) {
+		t.Fatalf("expected %d sensors, got %+v", len(expected), sensors)
+	}
+	for i, sensor := range sensors {
+		if sensor.Chip != expected[i].Chip || sensor.Label != expected[i].Label || sensor.Type != expected[i].Type || sensor.Value != expected[i].Value {
+			t.Errorf("unexpected sensor %d: %+v, expected %+v", i, sensor, expected[i])
+		}
+	}
+
+	if sensors[0].Max == nil || *sensors[0].Max != 80 || sensors[0].Critical == nil || *sensors[0].Critical != 100 {
+		t.Errorf("unexpected coretemp limits: %v %v", sensors[0].Max, sensors[0].Critical)
+	}
+	if zone := sensors[len(sensors)-1]; zone.Critical == nil || *zone.Critical != 105 {
+		t.Errorf("critical trip point wasn't picked up: %v", zone.Critical)
+	}
+}
+
+func TestGetSensorsSystemMissingRoot(t *testing.T) {
+	var handler Handler
+	handler.Config.Sensors.SysfsRoot = filepath.Join(t.TempDir(), "missing")
+	if sensors := GetSensorsSystem(&handler); len(sensors) != 0 {
+		t.Errorf("expected no sensors, got %+v", sensors)
+	}
+}
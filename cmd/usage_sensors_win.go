@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package main
+
+// Windows only exposes sensors through vendor tools, so there's nothing to read
+func GetSensorsSystem(handler *Handler) []Sensor {
+	return []Sensor{}
+}
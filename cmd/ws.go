@@ -1,522 +1,694 @@
-package main
-
-import (
-	"encoding/json"
-	"fmt"
-	"net/url"
-	"sync"
-	"time"
-
-	"github.com/gorilla/websocket"
-	"github.com/jwalton/gchalk"
-)
-
-const (
-	WebsocketMessageTypeAuth        string = "DAEMON_AUTH"
-	WebsocketMessageTypeAuthSuccess string = "DAEMON_AUTH_SUCCESS"
-	WebsocketMessageTypeAuthFailure string = "DAEMON_AUTH_FAILURE"
-
-	WebsocketMessageTypeRequestResources      string = "DAEMON_REQUEST_RESOURCES"
-	WebsocketMessageTypeRequestResourcesReply string = "DAEMON_REQUEST_RESOURCES_REPLY"
-	WebsocketMessageTypeRequestDatabaseBackup string = "DAEMON_REQUEST_DATABASE_BACKUP"
-
-	WebsocketMessageTypeRequestStats      string = "DAEMON_REQUEST_STATS"
-	WebsocketMessageTypeRequestStatsReply string = "DAEMON_REQUEST_STATS_REPLY"
-
-	WebsocketMessageTypeTaskProgress string = "DAEMON_TASK_PROGRESS"
-
-	WebsocketMessageTypeConnectContainerLog    string = "DAEMON_CONNECT_CONTAINER_LOG"
-	WebsocketMessageTypeRequestContainerLog    string = "DAEMON_REQUEST_CONTAINER_LOG"
-	WebsocketMessageTypeDisconnectContainerLog string = "DAEMON_DISCONNECT_CONTAINER_LOG"
-	WebsocketMessageTypeContainerLogMessage    string = "DAEMON_CONTAINER_LOG_MESSAGE"
-
-	WebsocketMessageTypeRequestContainerAction string = "DAEMON_REQUEST_CONTAINER_ACTION"
-
-	WebsocketMessageTypeBuildSmbConfig   string = "DAEMON_BUILD_SMB_CONFIG"
-	WebsocketMessageTypeBuildNginxConfig string = "DAEMON_BUILD_NGINX_CONFIG"
-)
-
-const (
-	WebsocketAuthFailureWrongToken      string = "WRONG_TOKEN"
-	WebsocketAuthFailureVersionMismatch string = "VERSION_MISMATCH"
-)
-
-type Session struct {
-	ID   string
-	Name string
-}
-
-type WebsocketMessage struct {
-	Type string `json:"type"`
-}
-
-const (
-	TaskStatusRunning  string = "RUNNING"
-	TaskStatusFailed   string = "FAILED"
-	TaskStatusFinished string = "FINISHED"
-)
-
-const (
-	ContainerActionStart   string = "START"
-	ContainerActionStop    string = "STOP"
-	ContainerActionBuild   string = "BUILD"
-	ContainerActionRemove  string = "REMOVE"
-	ContainerActionRestart string = "RESTART"
-	ContainerActionRebuild string = "REBUILD"
-)
-
-type WebsocketAuthMessage struct {
-	Type      string   `json:"type"`
-	Token     string   `json:"token"`
-	Version   string   `json:"version"`
-	Databases []string `json:"databases"`
-}
-
-type WebsocketAuthSuccessMessage struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
-	Name string `json:"name"`
-}
-
-type WebsocketAuthFailureMessage struct {
-	Type   string `json:"type"`
-	Reason string `json:"reason"`
-}
-
-type WebsocketAuthFailureVersionMismatchMessage struct {
-	Type    string `json:"type"`
-	Reason  string `json:"reason"`
-	Version string `json:"version"`
-}
-
-type WebsocketRequestResourcesMessage struct {
-	Type      string   `json:"type"`
-	Resources []string `json:"resources"`
-}
-
-const (
-	WebsocketResourcesGeneralType    string = "GENERAL"
-	WebsocketResourcesContainersType string = "CONTAINERS"
-	WebsocketResourcesDisksType      string = "DISKS"
-	WebsocketResourcesProcessesType  string = "PROCESSES"
-)
-
-type WebsocketRequestResourcesReplyMessage struct {
-	Type              string             `json:"type"`
-	Memory            *MemoryState       `json:"memory"`
-	Software          []Software         `json:"software"`
-	Disks             []Disk             `json:"disks"`
-	ZFS               []ZFSPool          `json:"zfs"`
-	Containers        []Container        `json:"containers"`
-	ContainerProjects []ContainerProject `json:"containerProjects"`
-	Processes         []Process          `json:"processes"`
-}
-
-type WebsocketRequestDatabaseBackupMessage struct {
-	Type     string `json:"type"`
-	Database string `json:"database"`
-	Data     bool   `json:"data"`
-	Task     string `json:"task"`
-	File     string `json:"file"`
-}
-
-type WebsocketRequestStatsReplyMessage struct {
-	Type       string           `json:"type"`
-	CPU        CPUUsage         `json:"cpu"`
-	Memory     MemoryUsage      `json:"memory"`
-	Disks      []DiskUsage      `json:"disks"`
-	Network    NetworkUsage     `json:"network"`
-	Containers []ContainerUsage `json:"containers"`
-}
-
-type WebsocketTaskProgressMessage struct {
-	Type     string  `json:"type"`
-	ID       string  `json:"id"`
-	Progress float32 `json:"progress"`
-	Status   string  `json:"status"`
-}
-
-type WebsocketConnectContainerLogMessage struct {
-	Type      string                             `json:"type"`
-	Container WebsocketConnectContainerContainer `json:"container"`
-	Options   WebsocketConnectContainerOptions   `json:"options"`
-}
-type WebsocketConnectContainerContainer struct {
-	ID   string  `json:"id"`
-	Name string  `json:"name"`
-	Path *string `json:"path"`
-}
-type WebsocketConnectContainerOptions struct {
-	Project bool   `json:"project"`
-	Tail    uint32 `json:"tail"`
-}
-
-type WebsocketRequestContainerLogMessage struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
-	Task string `json:"task"`
-}
-
-type WebsocketDisconnectContainerLogMessage struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
-}
-
-type WebsocketContainerLogMessageMessage struct {
-	Type    string `json:"type"`
-	ID      string `json:"id"`
-	Message string `json:"message"`
-}
-
-type WebsocketRequestContainerActionMessage struct {
-	Type   string `json:"type"`
-	ID     string `json:"id"`
-	Action string `json:"action"`
-}
-
-type WebsocketBuildSmbConfigMessage struct {
-	Type   string `json:"type"`
-	Config string `json:"config"`
-}
-
-type WebsocketBuildNginxConfigMessage struct {
-	Type        string                    `json:"type"`
-	Config      string                    `json:"config"`
-	Dockerfile  string                    `json:"dockerfile"`
-	NginxConfig string                    `json:"nginxConfig"`
-	Servers     []NginxConfigServerConfig `json:"servers"`
-	Networks    []string                  `json:"networks"`
-}
-type NginxConfigServerConfig struct {
-	Name   string `json:"name"`
-	Domain string `json:"domain"`
-	Ssl    string `json:"ssl"`
-	Config string `json:"config"`
-}
-
-func ConnectWebsocket(handler *Handler) *websocket.Conn {
-	u := url.URL{Host: handler.Config.DaemonHost, Path: "/socket", Scheme: "wss"}
-	SleepyLogLn("Connecting to %s...", u.String())
-
-	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
-	if err != nil {
-		SleepyWarnLn("Failed to connect (%s)! Reconnecting in %d s...", err.Error(), handler.Config.ReconnectTimeout)
-		return nil
-	}
-	SleepyLogLn("Connected!")
-	return ws
-}
-
-func AuthWebsocket(handler *Handler) {
-	authMessage := WebsocketAuthMessage{
-		Type:      WebsocketMessageTypeAuth,
-		Token:     handler.Config.Token,
-		Version:   DaemonVersion,
-		Databases: []string{},
-	}
-	for _, e := range handler.Credentials.Databases {
-		for _, j := range e.Databases {
-			authMessage.Databases = append(authMessage.Databases, j.ID)
-		}
-	}
-	SendWebsocketMessage(handler, authMessage)
-}
-
-func SendWebsocketMessage(handler *Handler, message any) error {
-	handler.WSMutex.Lock()
-	defer handler.WSMutex.Unlock()
-	return handler.WS.WriteJSON(message)
-}
-
-func ProcessWebsocket(handler *Handler, ws *websocket.Conn) error {
-	for {
-		_, messageRaw, err := ws.ReadMessage()
-		if err != nil {
-			SleepyWarnLn("Disconnected (%s)! Reconnecting in %d s...", err.Error(), handler.Config.ReconnectTimeout)
-			return err
-		}
-		var messageBase WebsocketMessage
-		err = json.Unmarshal(messageRaw, &messageBase)
-		if err != nil {
-			SleepyWarnLn("Failed to parse websocket message! (%s)", err.Error())
-			continue
-		}
-		SleepyLogLn("Got message of type %s", messageBase.Type)
-
-		switch messageBase.Type {
-		case WebsocketMessageTypeAuthSuccess:
-			var message WebsocketAuthSuccessMessage
-			_ = json.Unmarshal(messageRaw, &message)
-			handler.Session = &Session{
-				ID:   message.ID,
-				Name: message.Name,
-			}
-			SleepyLogLn("Logged in as %s! (id: %s)", handler.Session.Name, handler.Session.ID)
-			InitSnapshot(handler)
-		case WebsocketMessageTypeAuthFailure:
-			var message WebsocketAuthFailureMessage
-			_ = json.Unmarshal(messageRaw, &message)
-			switch message.Reason {
-			case WebsocketAuthFailureWrongToken:
-				SleepyErrorLn("Incorrect token! Closing the deamon...")
-				closeDaemon(handler)
-			case WebsocketAuthFailureVersionMismatch:
-				var message WebsocketAuthFailureVersionMismatchMessage
-				_ = json.Unmarshal(messageRaw, &message)
-				SleepyWarnLn("Version mismatch! Current version %s is not needed %s! Updating...", gchalk.Red(DaemonVersion), gchalk.Green(message.Version))
-				err := Update(handler, message.Version)
-				if err != nil {
-					return err
-				}
-			default:
-				return fmt.Errorf("failed to auth: %s", message.Reason)
-			}
-		case WebsocketMessageTypeRequestResources:
-			var message WebsocketRequestResourcesMessage
-			_ = json.Unmarshal(messageRaw, &message)
-			requestResourcesReplyMessage := GetResourcesMessage(handler, message.Resources)
-			SendWebsocketMessage(handler, requestResourcesReplyMessage)
-		case WebsocketMessageTypeRequestDatabaseBackup:
-			var message WebsocketRequestDatabaseBackupMessage
-			_ = json.Unmarshal(messageRaw, &message)
-
-			taskProgressMessage := WebsocketTaskProgressMessage{
-				Type:   WebsocketMessageTypeTaskProgress,
-				ID:     message.Task,
-				Status: TaskStatusRunning,
-			}
-			var path string
-			if message.Data {
-				path, err = CreateBackup(handler, message.Database)
-			} else {
-				path, err = CreateBackup(handler, message.Database, "--no-data")
-			}
-			if err != nil {
-				SleepyWarnLn("Failed to create a database backup! (%s)", err.Error())
-				taskProgressMessage.Status = TaskStatusFailed
-				SendWebsocketMessage(handler, taskProgressMessage)
-				continue
-			}
-
-			uploadFileData := UploadFileBackupDatabaseData{
-				Type:     UploadFileDataBackupDatabase,
-				Database: message.Database,
-				Task:     message.Task,
-			}
-			err = UploadFile(handler, path, uploadFileData)
-			if err != nil {
-				SleepyWarnLn("Failed to upload database backup! (%s)", err.Error())
-				taskProgressMessage.Status = TaskStatusFailed
-				SendWebsocketMessage(handler, taskProgressMessage)
-				continue
-			}
-			taskProgressMessage.Status = TaskStatusFinished
-			taskProgressMessage.Progress = 100
-			SendWebsocketMessage(handler, taskProgressMessage)
-		case WebsocketMessageTypeRequestStats:
-			requestStatsReplyMessage := GetStatsMessage(handler)
-			requestStatsReplyMessage.Type = WebsocketMessageTypeRequestStatsReply
-			SendWebsocketMessage(handler, requestStatsReplyMessage)
-		case WebsocketMessageTypeConnectContainerLog:
-			var message WebsocketConnectContainerLogMessage
-			_ = json.Unmarshal(messageRaw, &message)
-
-			ConnectContainerLogger(handler, message.Container, message.Options)
-		case WebsocketMessageTypeRequestContainerLog:
-			var message WebsocketRequestContainerLogMessage
-			_ = json.Unmarshal(messageRaw, &message)
-
-			for _, container := range handler.LastCache.Containers {
-				if container.ID == message.ID {
-					RequestContainerLog(handler, container, message.Task)
-				}
-			}
-		case WebsocketMessageTypeDisconnectContainerLog:
-			var message WebsocketDisconnectContainerLogMessage
-			_ = json.Unmarshal(messageRaw, &message)
-
-			DisconnectContainerLogger(handler, message.ID)
-		case WebsocketMessageTypeRequestContainerAction:
-			var message WebsocketRequestContainerActionMessage
-			_ = json.Unmarshal(messageRaw, &message)
-
-			for _, container := range handler.LastCache.Containers {
-				if container.ID == message.ID {
-					ProcessActionOnContainer(handler, container, message.Action)
-				}
-			}
-			for _, containerProject := range handler.LastCache.ContainerProjects {
-				if containerProject.ID == message.ID {
-					ProcessActionOnContainerProject(handler, containerProject, message.Action)
-				}
-			}
-		case WebsocketMessageTypeBuildSmbConfig:
-			var message WebsocketBuildSmbConfigMessage
-			_ = json.Unmarshal(messageRaw, &message)
-
-			RebuildSmbConfig(handler, message.Config)
-		case WebsocketMessageTypeBuildNginxConfig:
-			var message WebsocketBuildNginxConfigMessage
-			_ = json.Unmarshal(messageRaw, &message)
-
-			RebuildNginxConfig(handler, message)
-		}
-	}
-}
-
-func GetResourcesMessage(handler *Handler, resources []string) WebsocketRequestResourcesReplyMessage {
-	message := WebsocketRequestResourcesReplyMessage{
-		Type: WebsocketMessageTypeRequestResourcesReply,
-	}
-
-	var wg sync.WaitGroup
-	for _, resource := range resources {
-		wg.Add(1)
-		go func(resource string) {
-			defer wg.Done()
-			switch resource {
-			case WebsocketResourcesGeneralType:
-				memory, _ := GetMemoryDetails()
-				message.Memory = &memory
-				message.Software = GetInstalledSoftware()
-			case WebsocketResourcesContainersType:
-				message.Containers, message.ContainerProjects = GetContainers(handler)
-				handler.LastCache.Containers = message.Containers
-				handler.LastCache.ContainerProjects = message.ContainerProjects
-			case WebsocketResourcesDisksType:
-				message.Disks = GetDisks()
-				message.ZFS = GetZFSPools(message.Disks)
-			case WebsocketResourcesProcessesType:
-				message.Processes = GetProcesses()
-			}
-		}(resource)
-	}
-	wg.Wait()
-
-	return message
-}
-
-func GetStatsMessage(handler *Handler) WebsocketRequestStatsReplyMessage {
-	timeDiff := uint64(time.Since(handler.LastSnapshot.Timestamp).Seconds())
-	handler.LastSnapshot.Timestamp = time.Now()
-	message := WebsocketRequestStatsReplyMessage{
-		CPU:   CPUUsage{},
-		Disks: []DiskUsage{},
-	}
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		_, memory := GetMemoryDetails()
-		message.Memory = memory
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		rawCpuUsage := GetCPUUsage()
-		rawCpuTotal := float32(rawCpuUsage.Total - handler.LastSnapshot.RawCPUUsage.Total)
-		message.CPU = CPUUsage{
-			User:   (float32(rawCpuUsage.User-handler.LastSnapshot.RawCPUUsage.User) / rawCpuTotal) * 100,
-			System: (float32(rawCpuUsage.System-handler.LastSnapshot.RawCPUUsage.System) / rawCpuTotal) * 100,
-		}
-		handler.LastSnapshot.RawCPUUsage = rawCpuUsage
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		networkUsage := GetNetworkUsage()
-		message.Network = NetworkUsage{
-			RX: MathMinUint(networkUsage.RX-handler.LastSnapshot.NetworkUsage.RX, 0),
-			TX: MathMinUint(networkUsage.TX-handler.LastSnapshot.NetworkUsage.TX, 0),
-		}
-		handler.LastSnapshot.NetworkUsage = networkUsage
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		containerUsagesSnapshot := GetContainerUsages(handler)
-		var containerUsages []ContainerUsage = []ContainerUsage{}
-		for _, containerUsageSnapshot := range containerUsagesSnapshot {
-			lastContainerUsageIndex := -1
-			for i, tempContainerUsage := range handler.LastSnapshot.ContainerUsages {
-				if containerUsageSnapshot.Parent == tempContainerUsage.Parent {
-					lastContainerUsageIndex = i
-				}
-			}
-			if lastContainerUsageIndex == -1 {
-				continue
-			}
-			lastRX := MathMinZeroUint(containerUsageSnapshot.RX, handler.LastSnapshot.ContainerUsages[lastContainerUsageIndex].RX)
-			lastTX := MathMinZeroUint(containerUsageSnapshot.TX, handler.LastSnapshot.ContainerUsages[lastContainerUsageIndex].TX)
-			lastRead := MathMinZeroUint(containerUsageSnapshot.Read, handler.LastSnapshot.ContainerUsages[lastContainerUsageIndex].Read)
-			lastWrite := MathMinZeroUint(containerUsageSnapshot.Write, handler.LastSnapshot.ContainerUsages[lastContainerUsageIndex].Write)
-
-			containerUsage := ContainerUsage{
-				Parent: containerUsageSnapshot.Parent,
-				RX:     containerUsageSnapshot.RX - lastRX,
-				TX:     containerUsageSnapshot.TX - lastTX,
-				CPU:    containerUsageSnapshot.CPU,
-				Memory: containerUsageSnapshot.Memory,
-				Read:   (containerUsageSnapshot.Read - lastRead) / uint64(timeDiff),
-				Write:  (containerUsageSnapshot.Write - lastWrite) / uint64(timeDiff),
-			}
-			containerUsages = append(containerUsages, containerUsage)
-		}
-		message.Containers = containerUsages
-		handler.LastSnapshot.ContainerUsages = containerUsagesSnapshot
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		disks := GetDisks()
-		diskUsagesSnapshot := GetDiskUsages()
-		var diskUsages []DiskUsage = []DiskUsage{}
-		for _, rawDiskUsage := range diskUsagesSnapshot {
-			lastDiskUsageIndex := -1
-			for i, diskUsage := range handler.LastSnapshot.RawDiskUsages {
-				if diskUsage.Name == rawDiskUsage.Name {
-					lastDiskUsageIndex = i
-				}
-			}
-			if lastDiskUsageIndex == -1 {
-				continue
-			}
-			matchingDiskIndex := -1
-			for i, disk := range disks {
-				if disk.Name == rawDiskUsage.Name {
-					matchingDiskIndex = i
-				}
-			}
-			if matchingDiskIndex == -1 {
-				continue
-			}
-			readsDiff := rawDiskUsage.Reads - handler.LastSnapshot.RawDiskUsages[lastDiskUsageIndex].Reads
-			if readsDiff <= 0 {
-				readsDiff = 1
-			}
-			writesDiff := rawDiskUsage.Writes - handler.LastSnapshot.RawDiskUsages[lastDiskUsageIndex].Writes
-			if writesDiff <= 0 {
-				writesDiff = 1
-			}
-
-			diskUsages = append(diskUsages, DiskUsage{
-				Parent:       disks[matchingDiskIndex].ID,
-				Read:         ((rawDiskUsage.ReadSectors - handler.LastSnapshot.RawDiskUsages[lastDiskUsageIndex].ReadSectors) * 512) / timeDiff,
-				Write:        ((rawDiskUsage.WriteSectors - handler.LastSnapshot.RawDiskUsages[lastDiskUsageIndex].WriteSectors) * 512) / timeDiff,
-				ReadLatency:  ((rawDiskUsage.ReadTime - handler.LastSnapshot.RawDiskUsages[lastDiskUsageIndex].ReadTime) / readsDiff),
-				WriteLatency: ((rawDiskUsage.WriteTime - handler.LastSnapshot.RawDiskUsages[lastDiskUsageIndex].WriteTime) / writesDiff),
-			})
-			// SleepyLogLn("Adding... (name: %s, id: %s, read: %v, write: %v, timeDiff: %v)", disks[matchingDiskIndex].Name, disks[matchingDiskIndex].ID, diskUsage.Read, diskUsage.Write, timeDiff)
-		}
-		message.Disks = diskUsages
-		handler.LastSnapshot.RawDiskUsages = diskUsagesSnapshot
-	}()
-	wg.Wait()
-
-	return message
-}
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jwalton/gchalk"
+)
+
+const (
+	WebsocketMessageTypeAuth        string = "DAEMON_AUTH"
+	WebsocketMessageTypeAuthSuccess string = "DAEMON_AUTH_SUCCESS"
+	WebsocketMessageTypeAuthFailure string = "DAEMON_AUTH_FAILURE"
+
+	WebsocketMessageTypeRequestResources       string = "DAEMON_REQUEST_RESOURCES"
+	WebsocketMessageTypeRequestResourcesReply  string = "DAEMON_REQUEST_RESOURCES_REPLY"
+	WebsocketMessageTypeRequestDatabaseBackup  string = "DAEMON_REQUEST_DATABASE_BACKUP"
+	WebsocketMessageTypeRequestVolumeBackup    string = "DAEMON_REQUEST_VOLUME_BACKUP"
+	WebsocketMessageTypeRequestDatabaseRestore string = "DAEMON_REQUEST_DATABASE_RESTORE"
+
+	WebsocketMessageTypeRequestStats      string = "DAEMON_REQUEST_STATS"
+	WebsocketMessageTypeRequestStatsReply string = "DAEMON_REQUEST_STATS_REPLY"
+	WebsocketMessageTypeSubscribeStats    string = "DAEMON_SUBSCRIBE_STATS"
+
+	WebsocketMessageTypeAlert string = "DAEMON_ALERT"
+
+	WebsocketMessageTypeSetProbes string = "DAEMON_SET_PROBES"
+
+	WebsocketMessageTypeRequestStatsHistory      string = "DAEMON_REQUEST_STATS_HISTORY"
+	WebsocketMessageTypeRequestStatsHistoryReply string = "DAEMON_REQUEST_STATS_HISTORY_REPLY"
+
+	WebsocketMessageTypeTaskProgress string = "DAEMON_TASK_PROGRESS"
+
+	WebsocketMessageTypeConnectContainerLog    string = "DAEMON_CONNECT_CONTAINER_LOG"
+	WebsocketMessageTypeRequestContainerLog    string = "DAEMON_REQUEST_CONTAINER_LOG"
+	WebsocketMessageTypeDisconnectContainerLog string = "DAEMON_DISCONNECT_CONTAINER_LOG"
+	WebsocketMessageTypeContainerLogMessage    string = "DAEMON_CONTAINER_LOG_MESSAGE"
+
+	WebsocketMessageTypeRequestContainerAction string = "DAEMON_REQUEST_CONTAINER_ACTION"
+
+	WebsocketMessageTypeRequestProcessAction string = "DAEMON_REQUEST_PROCESS_ACTION"
+
+	WebsocketMessageTypeBuildSmbConfig   string = "DAEMON_BUILD_SMB_CONFIG"
+	WebsocketMessageTypeBuildNginxConfig string = "DAEMON_BUILD_NGINX_CONFIG"
+)
+
+const (
+	WebsocketAuthFailureWrongToken      string = "WRONG_TOKEN"
+	WebsocketAuthFailureVersionMismatch string = "VERSION_MISMATCH"
+)
+
+type Session struct {
+	ID   string
+	Name string
+}
+
+type WebsocketMessage struct {
+	Type string `json:"type"`
+}
+
+const (
+	TaskStatusRunning  string = "RUNNING"
+	TaskStatusFailed   string = "FAILED"
+	TaskStatusFinished string = "FINISHED"
+)
+
+const (
+	ContainerActionStart   string = "START"
+	ContainerActionStop    string = "STOP"
+	ContainerActionBuild   string = "BUILD"
+	ContainerActionRemove  string = "REMOVE"
+	ContainerActionRestart string = "RESTART"
+	ContainerActionRebuild string = "REBUILD"
+	ContainerActionPause   string = "PAUSE"
+	ContainerActionUnpause string = "UNPAUSE"
+)
+
+type WebsocketAuthMessage struct {
+	Type      string   `json:"type"`
+	Token     string   `json:"token"`
+	Version   string   `json:"version"`
+	Databases []string `json:"databases"`
+}
+
+type WebsocketAuthSuccessMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebsocketAuthFailureMessage struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type WebsocketAuthFailureVersionMismatchMessage struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Version string `json:"version"`
+}
+
+type WebsocketRequestResourcesMessage struct {
+	Type      string   `json:"type"`
+	Resources []string `json:"resources"`
+}
+
+const (
+	WebsocketResourcesGeneralType     string = "GENERAL"
+	WebsocketResourcesContainersType  string = "CONTAINERS"
+	WebsocketResourcesDisksType       string = "DISKS"
+	WebsocketResourcesProcessesType   string = "PROCESSES"
+	WebsocketResourcesFilesystemsType string = "FILESYSTEMS"
+	WebsocketResourcesPackagesType    string = "PACKAGES"
+	WebsocketResourcesDaemonType      string = "DAEMON"
+	WebsocketResourcesProbesType      string = "PROBES"
+)
+
+type WebsocketRequestResourcesReplyMessage struct {
+	Type              string             `json:"type"`
+	Memory            *MemoryState       `json:"memory"`
+	Host              *HostInfo          `json:"host"`
+	Software          []Software         `json:"software"`
+	Disks             []Disk             `json:"disks"`
+	ZFS               []ZFSPool          `json:"zfs"`
+	Raid              []RaidArray        `json:"raid"`
+	LVM               []LVMVolumeGroup   `json:"lvm"`
+	Containers        []Container        `json:"containers"`
+	ContainerProjects []ContainerProject `json:"containerProjects"`
+	Processes         []Process          `json:"processes"`
+	ProcessDetails    []ProcessDetails   `json:"processDetails"`
+	Filesystems       []Filesystem       `json:"filesystems"`
+	Packages          []Package          `json:"packages"`
+	PackageUpdates    []PackageUpdate    `json:"packageUpdates"`
+	Daemon            *DaemonMetrics     `json:"daemon"`
+	Probes            []ProbeResult      `json:"probes"`
+}
+
+type WebsocketRequestDatabaseBackupMessage struct {
+	Type        string `json:"type"`
+	Database    string `json:"database"`
+	Data        bool   `json:"data"`
+	Verify      bool   `json:"verify"`
+	Destination string `json:"destination"`
+	Task        string `json:"task"`
+	File        string `json:"file"`
+}
+
+type WebsocketRequestVolumeBackupMessage struct {
+	Type        string   `json:"type"`
+	Volume      *string  `json:"volume"`
+	Path        *string  `json:"path"`
+	Quiesce     string   `json:"quiesce"`
+	Containers  []string `json:"containers"`
+	Destination string   `json:"destination"`
+	Task        string   `json:"task"`
+}
+
+// Without a Target the backup is restored into a new <database>_restore_<timestamp> database.
+type WebsocketRequestDatabaseRestoreMessage struct {
+	Type      string  `json:"type"`
+	Database  string  `json:"database"`
+	Timestamp int64   `json:"timestamp"`
+	Target    *string `json:"target"`
+	Task      string  `json:"task"`
+}
+
+// Interval is in milliseconds, 0 stops pushing stats.
+type WebsocketSubscribeStatsMessage struct {
+	Type     string `json:"type"`
+	Interval uint32 `json:"interval"`
+}
+
+// Replaces the probes the server defined, probes from the config are kept.
+type WebsocketSetProbesMessage struct {
+	Type   string        `json:"type"`
+	Probes []ConfigProbe `json:"probes"`
+}
+
+// Timestamp is when the alert changed state, which can be a while ago for queued alerts.
+type WebsocketAlertMessage struct {
+	Type      string  `json:"type"`
+	Rule      string  `json:"rule"`
+	Metric    string  `json:"metric"`
+	Target    string  `json:"target"`
+	Value     float64 `json:"value"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Severity  string  `json:"severity"`
+	State     string  `json:"state"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// From and To are unix timestamps in seconds, Resolution is the minimum in seconds.
+// No series selects every series recorded in the chosen tier.
+type WebsocketRequestStatsHistoryMessage struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Series     []string `json:"series"`
+	From       int64    `json:"from"`
+	To         int64    `json:"to"`
+	Resolution uint32   `json:"resolution"`
+}
+
+type WebsocketRequestStatsHistoryReplyMessage struct {
+	Type   string          `json:"type"`
+	ID     string          `json:"id"`
+	Series []HistorySeries `json:"series"`
+}
+
+type WebsocketRequestStatsReplyMessage struct {
+	Type       string           `json:"type"`
+	Timestamp  int64            `json:"timestamp"`
+	Elapsed    float64          `json:"elapsed"`
+	CPU        CPUUsage         `json:"cpu"`
+	Memory     MemoryUsage      `json:"memory"`
+	Disks      []DiskUsage      `json:"disks"`
+	Network    NetworkUsage     `json:"network"`
+	Containers []ContainerUsage `json:"containers"`
+	Sensors    []Sensor         `json:"sensors"`
+	Pressure   PressureUsage    `json:"pressure"`
+}
+
+type WebsocketTaskProgressMessage struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	Progress     float32             `json:"progress"`
+	Status       string              `json:"status"`
+	Verification *BackupVerification `json:"verification"`
+}
+
+type WebsocketConnectContainerLogMessage struct {
+	Type      string                             `json:"type"`
+	Container WebsocketConnectContainerContainer `json:"container"`
+	Options   WebsocketConnectContainerOptions   `json:"options"`
+}
+type WebsocketConnectContainerContainer struct {
+	ID   string  `json:"id"`
+	Name string  `json:"name"`
+	Path *string `json:"path"`
+}
+type WebsocketConnectContainerOptions struct {
+	Project bool   `json:"project"`
+	Tail    uint32 `json:"tail"`
+}
+
+type WebsocketRequestContainerLogMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Task string `json:"task"`
+}
+
+type WebsocketDisconnectContainerLogMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type WebsocketContainerLogMessageMessage struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+type WebsocketRequestContainerActionMessage struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+}
+
+type WebsocketRequestProcessActionMessage struct {
+	Type     string `json:"type"`
+	PID      int    `json:"pid"`
+	Action   string `json:"action"`
+	Signal   string `json:"signal"`
+	Class    string `json:"class"`
+	Priority int    `json:"priority"`
+	Task     string `json:"task"`
+}
+
+type WebsocketBuildSmbConfigMessage struct {
+	Type   string `json:"type"`
+	Config string `json:"config"`
+}
+
+type WebsocketBuildNginxConfigMessage struct {
+	Type        string                    `json:"type"`
+	Config      string                    `json:"config"`
+	Dockerfile  string                    `json:"dockerfile"`
+	NginxConfig string                    `json:"nginxConfig"`
+	Servers     []NginxConfigServerConfig `json:"servers"`
+	Networks    []string                  `json:"networks"`
+}
+type NginxConfigServerConfig struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Ssl    string `json:"ssl"`
+	Config string `json:"config"`
+}
+
+func ConnectWebsocket(handler *Handler) *websocket.Conn {
+	u := url.URL{Host: handler.Config.DaemonHost, Path: "/socket", Scheme: "wss"}
+	SleepyLogLn("Connecting to %s...", u.String())
+
+	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		SleepyWarnLn("Failed to connect (%s)! Reconnecting in %d s...", err.Error(), handler.Config.ReconnectTimeout)
+		return nil
+	}
+	SleepyLogLn("Connected!")
+	RecordDaemonConnection(handler)
+	return ws
+}
+
+func AuthWebsocket(handler *Handler) {
+	authMessage := WebsocketAuthMessage{
+		Type:      WebsocketMessageTypeAuth,
+		Token:     handler.Config.Token,
+		Version:   DaemonVersion,
+		Databases: []string{},
+	}
+	for _, e := range handler.Credentials.Databases {
+		for _, j := range e.Databases {
+			authMessage.Databases = append(authMessage.Databases, j.ID)
+		}
+	}
+	SendWebsocketMessage(handler, authMessage)
+}
+
+var ErrWebsocketDisconnected = errors.New("websocket is not connected")
+
+// The connection is swapped on every reconnect, so both fields are only touched together under WSStateMutex
+func SetWebsocket(handler *Handler, mutex *sync.Mutex, ws *websocket.Conn) {
+	handler.WSStateMutex.Lock()
+	defer handler.WSStateMutex.Unlock()
+	handler.WSMutex = mutex
+	handler.WS = ws
+}
+
+func GetWebsocket(handler *Handler) (*sync.Mutex, *websocket.Conn) {
+	handler.WSStateMutex.Lock()
+	defer handler.WSStateMutex.Unlock()
+	return handler.WSMutex, handler.WS
+}
+
+// Background tasks check the session to tell whether the daemon is logged in
+func SetSession(handler *Handler, session *Session) {
+	handler.WSStateMutex.Lock()
+	defer handler.WSStateMutex.Unlock()
+	handler.Session = session
+}
+
+func GetSession(handler *Handler) *Session {
+	handler.WSStateMutex.Lock()
+	defer handler.WSStateMutex.Unlock()
+	return handler.Session
+}
+
+// Long running tasks keep sending after a disconnect, so this must not assume a live connection
+func SendWebsocketMessage(handler *Handler, message any) error {
+	raw, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	mutex, ws := GetWebsocket(handler)
+	if mutex == nil || ws == nil {
+		return ErrWebsocketDisconnected
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	RecordDaemonBytesSent(handler, len(raw))
+	return ws.WriteMessage(websocket.TextMessage, raw)
+}
+
+func ProcessWebsocket(handler *Handler, ws *websocket.Conn) error {
+	for {
+		_, messageRaw, err := ws.ReadMessage()
+		if err != nil {
+			SleepyWarnLn("Disconnected (%s)! Reconnecting in %d s...", err.Error(), handler.Config.ReconnectTimeout)
+			return err
+		}
+		var messageBase WebsocketMessage
+		err = json.Unmarshal(messageRaw, &messageBase)
+		if err != nil {
+			SleepyWarnLn("Failed to parse websocket message! (%s)", err.Error())
+			RecordDaemonMessage(handler, DaemonMessageTypeInvalid, len(messageRaw), 0)
+			continue
+		}
+		SleepyLogLn("Got message of type %s", messageBase.Type)
+		messageStart := time.Now()
+
+		switch messageBase.Type {
+		case WebsocketMessageTypeAuthSuccess:
+			var message WebsocketAuthSuccessMessage
+			_ = json.Unmarshal(messageRaw, &message)
+			session := &Session{
+				ID:   message.ID,
+				Name: message.Name,
+			}
+			SetSession(handler, session)
+			SleepyLogLn("Logged in as %s! (id: %s)", session.Name, session.ID)
+			handler.StatsManager.Mutex.Lock()
+			InitSnapshot(handler)
+			handler.StatsManager.Mutex.Unlock()
+			go UploadPendingBackups(handler)
+			go SendPendingAlerts(handler)
+		case WebsocketMessageTypeAuthFailure:
+			var message WebsocketAuthFailureMessage
+			_ = json.Unmarshal(messageRaw, &message)
+			switch message.Reason {
+			case WebsocketAuthFailureWrongToken:
+				SleepyErrorLn("Incorrect token! Closing the deamon...")
+				closeDaemon(handler)
+			case WebsocketAuthFailureVersionMismatch:
+				var message WebsocketAuthFailureVersionMismatchMessage
+				_ = json.Unmarshal(messageRaw, &message)
+				SleepyWarnLn("Version mismatch! Current version %s is not needed %s! Updating...", gchalk.Red(DaemonVersion), gchalk.Green(message.Version))
+				err := Update(handler, message.Version)
+				if err != nil {
+					NotifyTaskFailure(handler, "Update to "+message.Version, err)
+					return err
+				}
+			default:
+				return fmt.Errorf("failed to auth: %s", message.Reason)
+			}
+		case WebsocketMessageTypeRequestResources:
+			var message WebsocketRequestResourcesMessage
+			_ = json.Unmarshal(messageRaw, &message)
+			requestResourcesReplyMessage := GetResourcesMessage(handler, message.Resources)
+			SendWebsocketMessage(handler, requestResourcesReplyMessage)
+		case WebsocketMessageTypeRequestDatabaseBackup:
+			var message WebsocketRequestDatabaseBackupMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			go RequestDatabaseBackup(handler, message)
+		case WebsocketMessageTypeRequestVolumeBackup:
+			var message WebsocketRequestVolumeBackupMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			go RequestVolumeBackup(handler, message)
+		case WebsocketMessageTypeRequestDatabaseRestore:
+			var message WebsocketRequestDatabaseRestoreMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			go RequestDatabaseRestore(handler, message)
+		case WebsocketMessageTypeRequestStats:
+			requestStatsReplyMessage := SampleStats(handler)
+			SendWebsocketMessage(handler, requestStatsReplyMessage)
+		case WebsocketMessageTypeSubscribeStats:
+			var message WebsocketSubscribeStatsMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			SubscribeStats(handler, message.Interval)
+		case WebsocketMessageTypeRequestStatsHistory:
+			var message WebsocketRequestStatsHistoryMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			go RequestStatsHistory(handler, message)
+		case WebsocketMessageTypeSetProbes:
+			var message WebsocketSetProbesMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			SetServerProbes(handler, message.Probes)
+		case WebsocketMessageTypeConnectContainerLog:
+			var message WebsocketConnectContainerLogMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			ConnectContainerLogger(handler, message.Container, message.Options)
+		case WebsocketMessageTypeRequestContainerLog:
+			var message WebsocketRequestContainerLogMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			containers, _ := GetCachedContainers(handler)
+			for _, container := range containers {
+				if container.ID == message.ID {
+					RequestContainerLog(handler, container, message.Task)
+				}
+			}
+		case WebsocketMessageTypeDisconnectContainerLog:
+			var message WebsocketDisconnectContainerLogMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			DisconnectContainerLogger(handler, message.ID)
+		case WebsocketMessageTypeRequestContainerAction:
+			var message WebsocketRequestContainerActionMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			containers, containerProjects := GetCachedContainers(handler)
+			for _, container := range containers {
+				if container.ID == message.ID {
+					ProcessActionOnContainer(handler, container, message.Action)
+				}
+			}
+			for _, containerProject := range containerProjects {
+				if containerProject.ID == message.ID {
+					ProcessActionOnContainerProject(handler, containerProject, message.Action)
+				}
+			}
+		case WebsocketMessageTypeRequestProcessAction:
+			var message WebsocketRequestProcessActionMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			RequestProcessAction(handler, message)
+		case WebsocketMessageTypeBuildSmbConfig:
+			var message WebsocketBuildSmbConfigMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			RebuildSmbConfig(handler, message.Config)
+		case WebsocketMessageTypeBuildNginxConfig:
+			var message WebsocketBuildNginxConfigMessage
+			_ = json.Unmarshal(messageRaw, &message)
+
+			RebuildNginxConfig(handler, message)
+		}
+		RecordDaemonMessage(handler, messageBase.Type, len(messageRaw), time.Since(messageStart))
+	}
+}
+
+func GetResourcesMessage(handler *Handler, resources []string) WebsocketRequestResourcesReplyMessage {
+	message := WebsocketRequestResourcesReplyMessage{
+		Type: WebsocketMessageTypeRequestResourcesReply,
+	}
+
+	var wg sync.WaitGroup
+	for _, resource := range resources {
+		wg.Add(1)
+		go func(resource string) {
+			defer wg.Done()
+			switch resource {
+			case WebsocketResourcesGeneralType:
+				memory, _ := GetMemoryDetails()
+				message.Memory = &memory
+				host := GetHostInfo()
+				message.Host = &host
+				message.Software = GetInstalledSoftware()
+			case WebsocketResourcesContainersType:
+				message.Containers, message.ContainerProjects = GetContainers(handler)
+			case WebsocketResourcesDisksType:
+				message.Disks = AttachDisksSmart(handler, GetDisks())
+				message.ZFS = GetZFSPools(message.Disks)
+				message.Raid = GetRaidArrays(message.Disks)
+				message.LVM = GetLVMVolumeGroups(message.Disks)
+			case WebsocketResourcesProcessesType:
+				message.Processes, message.ProcessDetails = GetProcesses(handler)
+			case WebsocketResourcesFilesystemsType:
+				message.Filesystems = GetFilesystems(handler)
+			case WebsocketResourcesPackagesType:
+				message.Packages = GetInstalledPackages()
+				message.PackageUpdates = GetPackageUpdates()
+			case WebsocketResourcesDaemonType:
+				daemon := GetDaemonMetrics(handler)
+				message.Daemon = &daemon
+			case WebsocketResourcesProbesType:
+				message.Probes = GetProbeResults(handler)
+			}
+		}(resource)
+	}
+	wg.Wait()
+
+	// Processes read the cached containers while the others run, so it's only replaced once they're done
+	if message.Containers != nil {
+		SetCachedContainers(handler, message.Containers, message.ContainerProjects)
+	}
+
+	return message
+}
+
+func GetStatsMessage(handler *Handler) WebsocketRequestStatsReplyMessage {
+	now := time.Now()
+	elapsed := now.Sub(handler.LastSnapshot.Timestamp).Seconds()
+	handler.LastSnapshot.Timestamp = now
+	message := WebsocketRequestStatsReplyMessage{
+		Type:      WebsocketMessageTypeRequestStatsReply,
+		Timestamp: now.UnixMilli(),
+		Elapsed:   elapsed,
+		CPU:       CPUUsage{},
+		Disks:     []DiskUsage{},
+		Sensors:   []Sensor{},
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, memory := GetMemoryDetails()
+		message.Memory = memory
+		message.Pressure = GetPressureUsage()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rawCpuUsage := GetCPUUsage()
+		cpuUsage := GetCPUUsagePercentages(rawCpuUsage, handler.LastSnapshot.RawCPUUsage)
+		cpuUsage.Load = GetCPULoad()
+		cpuUsage.ContextSwitches = MathRate(MathDeltaUint(rawCpuUsage.ContextSwitches, handler.LastSnapshot.RawCPUUsage.ContextSwitches), elapsed)
+		cpuUsage.Interrupts = MathRate(MathDeltaUint(rawCpuUsage.Interrupts, handler.LastSnapshot.RawCPUUsage.Interrupts), elapsed)
+		message.CPU = cpuUsage
+		handler.LastSnapshot.RawCPUUsage = rawCpuUsage
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rawNetworkUsage := GetNetworkUsage(handler)
+		message.Network = GetNetworkUsageRates(rawNetworkUsage, handler.LastSnapshot.RawNetworkUsage, elapsed)
+		handler.LastSnapshot.RawNetworkUsage = rawNetworkUsage
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		message.Sensors = GetSensors(handler)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		containerUsagesSnapshot := GetContainerUsages(handler)
+		var containerUsages []ContainerUsage = []ContainerUsage{}
+		for _, containerUsageSnapshot := range containerUsagesSnapshot {
+			lastContainerUsageIndex := -1
+			for i, tempContainerUsage := range handler.LastSnapshot.ContainerUsages {
+				if containerUsageSnapshot.Parent == tempContainerUsage.Parent {
+					lastContainerUsageIndex = i
+				}
+			}
+			if lastContainerUsageIndex == -1 {
+				continue
+			}
+			lastContainerUsage := handler.LastSnapshot.ContainerUsages[lastContainerUsageIndex]
+
+			containerUsage := ContainerUsage{
+				Parent: containerUsageSnapshot.Parent,
+				RX:     MathRate(MathDeltaUint(containerUsageSnapshot.RX, lastContainerUsage.RX), elapsed),
+				TX:     MathRate(MathDeltaUint(containerUsageSnapshot.TX, lastContainerUsage.TX), elapsed),
+				CPU:    containerUsageSnapshot.CPU,
+				Memory: containerUsageSnapshot.Memory,
+				Read:   MathRate(MathDeltaUint(containerUsageSnapshot.Read, lastContainerUsage.Read), elapsed),
+				Write:  MathRate(MathDeltaUint(containerUsageSnapshot.Write, lastContainerUsage.Write), elapsed),
+			}
+			containerUsages = append(containerUsages, containerUsage)
+		}
+		message.Containers = containerUsages
+		handler.LastSnapshot.ContainerUsages = containerUsagesSnapshot
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		disks := GetDisks()
+		diskUsagesSnapshot := GetDiskUsages()
+		var diskUsages []DiskUsage = []DiskUsage{}
+		for _, rawDiskUsage := range diskUsagesSnapshot {
+			lastDiskUsageIndex := -1
+			for i, diskUsage := range handler.LastSnapshot.RawDiskUsages {
+				if diskUsage.Name == rawDiskUsage.Name {
+					lastDiskUsageIndex = i
+				}
+			}
+			if lastDiskUsageIndex == -1 {
+				continue
+			}
+			matchingDiskIndex := -1
+			for i, disk := range disks {
+				if disk.Name == rawDiskUsage.Name {
+					matchingDiskIndex = i
+				}
+			}
+			if matchingDiskIndex == -1 {
+				continue
+			}
+			lastDiskUsage := handler.LastSnapshot.RawDiskUsages[lastDiskUsageIndex]
+			readsDiff := MathMinUint(MathDeltaUint(rawDiskUsage.Reads, lastDiskUsage.Reads), 1)
+			writesDiff := MathMinUint(MathDeltaUint(rawDiskUsage.Writes, lastDiskUsage.Writes), 1)
+
+			diskUsages = append(diskUsages, DiskUsage{
+				Parent:       disks[matchingDiskIndex].ID,
+				Read:         MathRate(MathDeltaUint(rawDiskUsage.ReadSectors, lastDiskUsage.ReadSectors)*512, elapsed),
+				Write:        MathRate(MathDeltaUint(rawDiskUsage.WriteSectors, lastDiskUsage.WriteSectors)*512, elapsed),
+				ReadLatency:  MathDeltaUint(rawDiskUsage.ReadTime, lastDiskUsage.ReadTime) / readsDiff,
+				WriteLatency: MathDeltaUint(rawDiskUsage.WriteTime, lastDiskUsage.WriteTime) / writesDiff,
+			})
+		}
+		message.Disks = diskUsages
+		handler.LastSnapshot.RawDiskUsages = diskUsagesSnapshot
+	}()
+	wg.Wait()
+
+	return message
+}
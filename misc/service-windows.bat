@@ -1,6 +1,6 @@
-:: Settings
-@echo off
-set /p SLEEPY_VERSION=<current_version.txt
-
-:: Run
+:: Settings
+@echo off
+set /p SLEEPY_VERSION=<current_version.txt
+
+:: Run
 .\%SLEEPY_VERSION%\bin\sleepy-daemon.exe -config="current.json"
\ No newline at end of file
@@ -1,8 +1,8 @@
-:: Settings
-@echo off
-
-:: Build
-cd ..\cmd
-copy version.txt ..\..\current_version.txt
-go build -o ..\bin\sleepy-daemon.exe .
+:: Settings
+@echo off
+
+:: Build
+cd ..\cmd
+copy version.txt ..\..\current_version.txt
+go build -o ..\bin\sleepy-daemon.exe .
 cd ..\..
\ No newline at end of file
@@ -1,10 +1,10 @@
-:: Settings
-@echo off
-
-:: Build
-cd ..\cmd
-go build -o ..\bin\sleepy-daemon.exe .
-cd ..\..
-
-:: Run
+:: Settings
+@echo off
+
+:: Build
+cd ..\cmd
+go build -o ..\bin\sleepy-daemon.exe .
+cd ..\..
+
+:: Run
 .\dev\bin\sleepy-daemon.exe -config="dev.json" -d
\ No newline at end of file
@@ -1,3 +1,3 @@
-:: Run
-cd ..\..
+:: Run
+cd ..\..
 .\dev\bin\sleepy-daemon.exe -config="current.json"
\ No newline at end of file